@@ -11,6 +11,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"iter"
+	"math/bits"
 )
 
 // BitUint8 is a bit set implementation backed by a slice of uint8 values.
@@ -52,18 +54,34 @@ func (b BitUint8) WriteTo(w io.Writer) (n int64, err error) {
 
 // ReadFrom implements io.ReaderFrom.
 func (b *BitUint8) ReadFrom(r io.Reader) (n int64, err error) {
-	var sz int64
-	err = binary.Read(r, binary.LittleEndian, &sz)
+	sz, err := readSize(r)
 	if err != nil {
-		return n, fmt.Errorf("cannot decode size of bitset: %w", err)
+		return n, err
 	}
 	n += 8
+	if sz == 0 {
+		*b = BitUint8{}
+		return n, nil
+	}
 	*b = NewBitUint8(int(sz))
 	nr, err := io.ReadFull(r, *b)
 	n += int64(nr)
 	return n, err
 }
 
+// readSize reads the number of bits of an encoded bitset.
+func readSize(r io.Reader) (int64, error) {
+	var sz int64
+	err := binary.Read(r, binary.LittleEndian, &sz)
+	if err != nil {
+		return 0, fmt.Errorf("cannot decode size of bitset: %w", err)
+	}
+	if sz < 0 {
+		return 0, fmt.Errorf("cannot decode bitset: invalid size %d", sz)
+	}
+	return sz, nil
+}
+
 // NewBitUint8 creates a new BitUint8 with capacity for at least n bits.
 func NewBitUint8(n int) BitUint8 {
 	assert(n > 0, "n must be positive")
@@ -71,6 +89,226 @@ func NewBitUint8(n int) BitUint8 {
 	return make(BitUint8, (n+7)/8)
 }
 
+// BitUint64 is a bit set implementation backed by a slice of uint64 values.
+// It can dynamically grow to accommodate new elements and shares its wire
+// format with [BitUint8].
+type BitUint64 []uint64
+
+// Has checks if the bit at position n is set.
+// Returns false for any position beyond the current length.
+func (b BitUint64) Has(n int) bool {
+	pos := n / 64
+	if pos >= len(b) {
+		return false
+	}
+	return b[pos]&(uint64(1)<<(n%64)) != 0
+}
+
+// Set sets or clears the bit at position n.
+func (b *BitUint64) Set(n int, t bool) {
+	pos := n / 64
+	j := uint(n % 64)
+	if pos >= len(*b) {
+		if !t {
+			return
+		}
+		b.grow(pos + 1)
+	}
+	if t {
+		(*b)[pos] |= uint64(1) << j
+	} else {
+		(*b)[pos] &= ^(uint64(1) << j)
+	}
+}
+
+func (b *BitUint64) grow(size int) {
+	b2 := make(BitUint64, size)
+	copy(b2, *b)
+	*b = b2
+}
+
+// Len returns the total number of bits in the set.
+func (b BitUint64) Len() int { return 64 * len(b) }
+
+// Count returns the number of set bits.
+func (b BitUint64) Count() int {
+	var n int
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// NextSet returns the position of the first set bit at or after n.
+// It reports false if there is no such bit.
+func (b BitUint64) NextSet(n int) (int, bool) {
+	pos := n / 64
+	if pos >= len(b) {
+		return 0, false
+	}
+	w := b[pos] >> (n % 64)
+	if w != 0 {
+		return n + bits.TrailingZeros64(w), true
+	}
+	for pos++; pos < len(b); pos++ {
+		if b[pos] != 0 {
+			return 64*pos + bits.TrailingZeros64(b[pos]), true
+		}
+	}
+	return 0, false
+}
+
+// NextClear returns the position of the first clear bit at or after n.
+// Bits beyond the current length are clear, so a position is always found.
+func (b BitUint64) NextClear(n int) int {
+	pos := n / 64
+	if pos >= len(b) {
+		return n
+	}
+	w := ^b[pos] >> (n % 64)
+	if w != 0 {
+		return n + bits.TrailingZeros64(w)
+	}
+	for pos++; pos < len(b); pos++ {
+		if b[pos] != ^uint64(0) {
+			return 64*pos + bits.TrailingZeros64(^b[pos])
+		}
+	}
+	return b.Len()
+}
+
+// All returns an iterator over the positions of the set bits in ascending order.
+func (b BitUint64) All() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for pos, w := range b {
+			for w != 0 {
+				j := bits.TrailingZeros64(w)
+				if !yield(64*pos + j) {
+					return
+				}
+				w &= w - 1
+			}
+		}
+	}
+}
+
+// And returns a new set containing the bits set in both b and c.
+func (b BitUint64) And(c BitUint64) BitUint64 {
+	d := b.clone(min(len(b), len(c)))
+	d.InPlaceAnd(c)
+	return d
+}
+
+// Or returns a new set containing the bits set in either b or c.
+func (b BitUint64) Or(c BitUint64) BitUint64 {
+	d := b.clone(max(len(b), len(c)))
+	d.InPlaceOr(c)
+	return d
+}
+
+// Xor returns a new set containing the bits set in exactly one of b and c.
+func (b BitUint64) Xor(c BitUint64) BitUint64 {
+	d := b.clone(max(len(b), len(c)))
+	d.InPlaceXor(c)
+	return d
+}
+
+// AndNot returns a new set containing the bits set in b but not in c.
+func (b BitUint64) AndNot(c BitUint64) BitUint64 {
+	d := b.clone(len(b))
+	d.InPlaceAndNot(c)
+	return d
+}
+
+// InPlaceAnd clears every bit in b that is not set in c.
+func (b *BitUint64) InPlaceAnd(c BitUint64) {
+	if len(*b) > len(c) {
+		*b = (*b)[:len(c)]
+	}
+	for i := range *b {
+		(*b)[i] &= c[i]
+	}
+}
+
+// InPlaceOr sets every bit in b that is set in c, growing b if needed.
+func (b *BitUint64) InPlaceOr(c BitUint64) {
+	if len(*b) < len(c) {
+		b.grow(len(c))
+	}
+	for i, w := range c {
+		(*b)[i] |= w
+	}
+}
+
+// InPlaceXor toggles every bit in b that is set in c, growing b if needed.
+func (b *BitUint64) InPlaceXor(c BitUint64) {
+	if len(*b) < len(c) {
+		b.grow(len(c))
+	}
+	for i, w := range c {
+		(*b)[i] ^= w
+	}
+}
+
+// InPlaceAndNot clears every bit in b that is set in c.
+func (b *BitUint64) InPlaceAndNot(c BitUint64) {
+	for i := range min(len(*b), len(c)) {
+		(*b)[i] &^= c[i]
+	}
+}
+
+func (b BitUint64) clone(size int) BitUint64 {
+	b2 := make(BitUint64, size)
+	copy(b2, b)
+	return b2
+}
+
+// WriteTo implements io.WriterTo.
+func (b BitUint64) WriteTo(w io.Writer) (n int64, err error) {
+	sz := int64(b.Len())
+	err = binary.Write(w, binary.LittleEndian, sz)
+	if err != nil {
+		return n, fmt.Errorf("cannot encode size of bitset: %w", err)
+	}
+	n += 8
+	p := make([]byte, 8*len(b))
+	for i, v := range b {
+		binary.LittleEndian.PutUint64(p[8*i:], v)
+	}
+	nw, err := w.Write(p)
+	n += int64(nw)
+	return n, err
+}
+
+// ReadFrom implements io.ReaderFrom.
+// Sizes that are not a multiple of 64 are rounded up to the next word.
+func (b *BitUint64) ReadFrom(r io.Reader) (n int64, err error) {
+	sz, err := readSize(r)
+	if err != nil {
+		return n, err
+	}
+	n += 8
+	if sz == 0 {
+		*b = BitUint64{}
+		return n, nil
+	}
+	*b = NewBitUint64(int(sz))
+	p := make([]byte, 8*len(*b))
+	nr, err := io.ReadFull(r, p[:(sz+7)/8])
+	n += int64(nr)
+	for i := range *b {
+		(*b)[i] = binary.LittleEndian.Uint64(p[8*i:])
+	}
+	return n, err
+}
+
+// NewBitUint64 creates a new BitUint64 with capacity for at least n bits.
+func NewBitUint64(n int) BitUint64 {
+	assert(n > 0, "n must be positive")
+
+	return make(BitUint64, (n+63)/64)
+}
+
 // BitBool is a bit set implementation backed by a slice of bool values.
 // It can dynamically grow to accommodate new elements.
 type BitBool []bool
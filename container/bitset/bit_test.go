@@ -6,7 +6,9 @@
 package bitset_test
 
 import (
+	"bytes"
 	"io"
+	"slices"
 	"testing"
 
 	. "go.adoublef.dev/container/bitset"
@@ -82,3 +84,168 @@ func TestBitBool(t *testing.T) {
 		}
 	})
 }
+
+func TestBitUint64(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Len", func(t *testing.T) {
+		t.Parallel()
+
+		b := new(BitUint64)
+		for j := 2; j < M; j += 1 {
+			b.Set(j, true)
+		}
+		if got, want := b.Len(), (M+63)/64*64; got != want {
+			t.Errorf("BitUint64.Len: got=%d;want=%d", got, want)
+		}
+		if got, want := b.Count(), M-2; got != want {
+			t.Errorf("BitUint64.Count: got=%d;want=%d", got, want)
+		}
+	})
+
+	t.Run("Next", func(t *testing.T) {
+		t.Parallel()
+
+		var b BitUint64
+		for _, j := range []int{3, 64, 200} {
+			b.Set(j, true)
+		}
+		var got []int
+		for j, ok := b.NextSet(0); ok; j, ok = b.NextSet(j + 1) {
+			got = append(got, j)
+		}
+		if want := []int{3, 64, 200}; !slices.Equal(got, want) {
+			t.Errorf("BitUint64.NextSet: got=%v;want=%v", got, want)
+		}
+		if got := slices.Collect(b.All()); !slices.Equal(got, []int{3, 64, 200}) {
+			t.Errorf("BitUint64.All: got=%v;want=%v", got, []int{3, 64, 200})
+		}
+
+		c := NewBitUint64(128)
+		for j := range 70 {
+			c.Set(j, true)
+		}
+		if got, want := c.NextClear(0), 70; got != want {
+			t.Errorf("BitUint64.NextClear: got=%d;want=%d", got, want)
+		}
+		if got, want := c.NextClear(1000), 1000; got != want {
+			t.Errorf("BitUint64.NextClear: got=%d;want=%d", got, want)
+		}
+	})
+
+	t.Run("Algebra", func(t *testing.T) {
+		t.Parallel()
+
+		var a, b BitUint64
+		for j := 0; j < 300; j += 2 {
+			a.Set(j, true)
+		}
+		for j := 0; j < 100; j += 3 {
+			b.Set(j, true)
+		}
+
+		for _, tc := range []struct {
+			name string
+			got  BitUint64
+			f    func(x, y bool) bool
+		}{
+			{"And", a.And(b), func(x, y bool) bool { return x && y }},
+			{"Or", a.Or(b), func(x, y bool) bool { return x || y }},
+			{"Xor", a.Xor(b), func(x, y bool) bool { return x != y }},
+			{"AndNot", a.AndNot(b), func(x, y bool) bool { return x && !y }},
+		} {
+			for j := range 320 {
+				if got, want := tc.got.Has(j), tc.f(a.Has(j), b.Has(j)); got != want {
+					t.Errorf("BitUint64.%s: bit %d got=%t;want=%t", tc.name, j, got, want)
+					break
+				}
+			}
+		}
+
+		c := slices.Clone(a)
+		c.InPlaceAnd(b)
+		if !slices.Equal(c, a.And(b)) {
+			t.Errorf("BitUint64.InPlaceAnd: differs from BitUint64.And")
+		}
+	})
+
+	t.Run("ReadFrom", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewBitUint8(M)
+		for j := 2; j < M; j += 13 {
+			a.Set(j, true)
+		}
+
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() {
+			defer pw.Close()
+			_, err := a.WriteTo(pw)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}()
+
+		// read a BitUint8 encoding
+		var b BitUint64
+		_, err := b.ReadFrom(pr)
+		if err != nil {
+			t.Errorf("BitUint64.ReadFrom: %v", err)
+		}
+		for j, n := 0, max(a.Len(), b.Len()); j < n; j++ {
+			if (j < a.Len() && a.Has(j)) != b.Has(j) {
+				t.Errorf("bitset %d differs at index %d", 1, j)
+				return
+			}
+		}
+
+		// and write it back as one
+		var buf bytes.Buffer
+		_, err = b.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("BitUint64.WriteTo: %v", err)
+		}
+		var c BitUint8
+		_, err = c.ReadFrom(&buf)
+		if err != nil {
+			t.Errorf("BitUint8.ReadFrom: %v", err)
+		}
+		if got, want := c.Len(), b.Len(); got != want {
+			t.Errorf("BitUint8.Len: got=%d;want=%d", got, want)
+		}
+		for j := range c.Len() {
+			if c.Has(j) != b.Has(j) {
+				t.Errorf("bitset %d differs at index %d", 2, j)
+				return
+			}
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		for _, a := range []BitUint64{{}, NewBitUint64(M).And(BitUint64{})} {
+			var buf bytes.Buffer
+			_, err := a.WriteTo(&buf)
+			if err != nil {
+				t.Errorf("BitUint64.WriteTo: %v", err)
+			}
+			var b BitUint64
+			_, err = b.ReadFrom(&buf)
+			if err != nil {
+				t.Errorf("BitUint64.ReadFrom: %v", err)
+			}
+			if b.Len() != a.Len() {
+				t.Errorf("BitUint64.Len: got=%d;want=%d", b.Len(), a.Len())
+			}
+		}
+
+		// a negative size is an error rather than a panic
+		var b BitUint64
+		_, err := b.ReadFrom(bytes.NewReader(bytes.Repeat([]byte{0xff}, 8)))
+		if err == nil {
+			t.Error("BitUint64.ReadFrom: got nil error for a negative size")
+		}
+	})
+}
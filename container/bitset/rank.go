@@ -0,0 +1,217 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+const (
+	blockWords = 8               // words per rank block
+	blockBits  = 64 * blockWords // bits per rank block
+	sampleRate = 512             // set bits between select samples
+)
+
+// RankSelect is an immutable bit vector with auxiliary directories for
+// constant time rank and near constant time select queries.
+type RankSelect struct {
+	bits    BitUint64
+	blocks  []uint64 // number of set bits before each block, plus the total
+	words   []uint16 // number of set bits before each word within its block
+	samples []uint32 // block holding every sampleRate-th set bit
+}
+
+// NewRankSelect creates a new [RankSelect] from a copy of b.
+func NewRankSelect[B BitUint8 | BitUint64](b B) *RankSelect {
+	var w BitUint64
+	switch b := any(b).(type) {
+	case BitUint8:
+		w = make(BitUint64, (len(b)+7)/8)
+		p := make([]byte, 8*len(w))
+		copy(p, b)
+		for i := range w {
+			w[i] = binary.LittleEndian.Uint64(p[8*i:])
+		}
+	case BitUint64:
+		w = b.clone(len(b))
+	}
+	rs := &RankSelect{bits: w}
+	rs.index()
+	return rs
+}
+
+// index builds the rank and select directories.
+func (rs *RankSelect) index() {
+	rs.blocks = make([]uint64, 0, (len(rs.bits)+blockWords-1)/blockWords+1)
+	rs.words = make([]uint16, len(rs.bits))
+	rs.samples = nil
+	var n uint64
+	for i, w := range rs.bits {
+		if i%blockWords == 0 {
+			rs.blocks = append(rs.blocks, n)
+		}
+		rs.words[i] = uint16(n - rs.blocks[i/blockWords])
+		c := uint64(bits.OnesCount64(w))
+		for s := uint64(len(rs.samples)) * sampleRate; s < n+c; s += sampleRate {
+			rs.samples = append(rs.samples, uint32(i/blockWords))
+		}
+		n += c
+	}
+	rs.blocks = append(rs.blocks, n)
+}
+
+// Has checks if the bit at position n is set.
+func (rs *RankSelect) Has(n int) bool { return rs.bits.Has(n) }
+
+// Len returns the total number of bits in the vector.
+func (rs *RankSelect) Len() int { return rs.bits.Len() }
+
+// Count returns the number of set bits.
+func (rs *RankSelect) Count() int { return int(rs.blocks[len(rs.blocks)-1]) }
+
+// Rank1 returns the number of set bits in positions [0, i).
+func (rs *RankSelect) Rank1(i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= rs.Len() {
+		return rs.Count()
+	}
+	pos := i / 64
+	n := rs.blocks[pos/blockWords] + uint64(rs.words[pos])
+	if j := i % 64; j != 0 {
+		n += uint64(bits.OnesCount64(rs.bits[pos] << (64 - j)))
+	}
+	return int(n)
+}
+
+// Rank0 returns the number of clear bits in positions [0, i).
+func (rs *RankSelect) Rank0(i int) int {
+	i = max(0, min(i, rs.Len()))
+	return i - rs.Rank1(i)
+}
+
+// Select1 returns the position of the k-th set bit, counting from zero.
+// It reports false if fewer than k+1 bits are set.
+func (rs *RankSelect) Select1(k int) (int, bool) {
+	if k < 0 || k >= rs.Count() {
+		return 0, false
+	}
+	// the k-th set bit lies between the blocks of the samples either side
+	lo, hi := int(rs.samples[k/sampleRate]), len(rs.blocks)-2
+	if s := k/sampleRate + 1; s < len(rs.samples) {
+		hi = int(rs.samples[s])
+	}
+	j := lo + sort.Search(hi-lo, func(i int) bool { return rs.blocks[lo+i+1] > uint64(k) })
+	r := k - int(rs.blocks[j])
+	for pos := j * blockWords; ; pos++ {
+		c := bits.OnesCount64(rs.bits[pos])
+		if r < c {
+			return 64*pos + selectWord(rs.bits[pos], r), true
+		}
+		r -= c
+	}
+}
+
+// Select0 returns the position of the k-th clear bit, counting from zero.
+// It reports false if fewer than k+1 bits are clear.
+func (rs *RankSelect) Select0(k int) (int, bool) {
+	if k < 0 || k >= rs.Len()-rs.Count() {
+		return 0, false
+	}
+	zeros := func(j int) int { return j*blockBits - int(rs.blocks[j]) }
+	// last block with fewer than k+1 clear bits before it
+	j := sort.Search(len(rs.blocks)-1, func(j int) bool { return zeros(j+1) > k })
+	r := k - zeros(j)
+	for pos := j * blockWords; ; pos++ {
+		c := 64 - bits.OnesCount64(rs.bits[pos])
+		if r < c {
+			return 64*pos + selectWord(^rs.bits[pos], r), true
+		}
+		r -= c
+	}
+}
+
+// selectWord returns the position of the r-th set bit in w.
+func selectWord(w uint64, r int) int {
+	for range r {
+		w &= w - 1
+	}
+	return bits.TrailingZeros64(w)
+}
+
+// WriteTo implements io.WriterTo. The bit vector is written in the same
+// format as [BitUint64] and followed by the rank and select directories.
+func (rs *RankSelect) WriteTo(w io.Writer) (n int64, err error) {
+	n, err = rs.bits.WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	for _, v := range []any{rs.blocks, rs.words, rs.samples} {
+		err = binary.Write(w, binary.LittleEndian, int64(binary.Size(v)))
+		if err != nil {
+			return n, fmt.Errorf("cannot encode size of directory: %w", err)
+		}
+		n += 8
+		err = binary.Write(w, binary.LittleEndian, v)
+		if err != nil {
+			return n, fmt.Errorf("cannot encode directory: %w", err)
+		}
+		n += int64(binary.Size(v))
+	}
+	return n, nil
+}
+
+// ReadFrom implements io.ReaderFrom. The directories read are checked
+// against the bit vector, so a corrupted encoding is reported as an error.
+func (rs *RankSelect) ReadFrom(r io.Reader) (n int64, err error) {
+	var got RankSelect // assigned to rs once it is known to be valid
+	n, err = got.bits.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	if got.blocks, err = readDirectory[uint64](r, &n); err != nil {
+		return n, err
+	}
+	if got.words, err = readDirectory[uint16](r, &n); err != nil {
+		return n, err
+	}
+	if got.samples, err = readDirectory[uint32](r, &n); err != nil {
+		return n, err
+	}
+	want := RankSelect{bits: got.bits}
+	want.index()
+	if !slices.Equal(got.blocks, want.blocks) || !slices.Equal(got.words, want.words) || !slices.Equal(got.samples, want.samples) {
+		return n, fmt.Errorf("cannot decode directory: does not match bit vector")
+	}
+	*rs = want
+	return n, nil
+}
+
+func readDirectory[E uint16 | uint32 | uint64](r io.Reader, n *int64) ([]E, error) {
+	var sz int64
+	err := binary.Read(r, binary.LittleEndian, &sz)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode size of directory: %w", err)
+	}
+	*n += 8
+	size := int64(binary.Size(E(0)))
+	if sz < 0 || sz%size != 0 {
+		return nil, fmt.Errorf("cannot decode directory: invalid size %d", sz)
+	}
+	dir := make([]E, sz/size)
+	err = binary.Read(r, binary.LittleEndian, dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode directory: %w", err)
+	}
+	*n += sz
+	return dir, nil
+}
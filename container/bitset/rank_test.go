@@ -0,0 +1,162 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+
+	. "go.adoublef.dev/container/bitset"
+)
+
+func TestRankSelect(t *testing.T) {
+	t.Parallel()
+
+	a := NewBitUint8(M)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for j := range M {
+		a.Set(j, rng.IntN(3) == 0)
+	}
+
+	t.Run("Rank", func(t *testing.T) {
+		t.Parallel()
+
+		rs := NewRankSelect(a)
+		var n int
+		for j := range a.Len() {
+			if got := rs.Rank1(j); got != n {
+				t.Errorf("RankSelect.Rank1(%d): got=%d;want=%d", j, got, n)
+				return
+			}
+			if got, want := rs.Rank0(j), j-n; got != want {
+				t.Errorf("RankSelect.Rank0(%d): got=%d;want=%d", j, got, want)
+				return
+			}
+			if a.Has(j) {
+				n++
+			}
+		}
+		if got := rs.Count(); got != n {
+			t.Errorf("RankSelect.Count: got=%d;want=%d", got, n)
+		}
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		t.Parallel()
+
+		rs := NewRankSelect(a)
+		var ones, zeros int
+		for j := range rs.Len() {
+			if rs.Has(j) {
+				if got, ok := rs.Select1(ones); !ok || got != j {
+					t.Errorf("RankSelect.Select1(%d): got=%d;want=%d", ones, got, j)
+					return
+				}
+				ones++
+			} else {
+				if got, ok := rs.Select0(zeros); !ok || got != j {
+					t.Errorf("RankSelect.Select0(%d): got=%d;want=%d", zeros, got, j)
+					return
+				}
+				zeros++
+			}
+		}
+		if _, ok := rs.Select1(ones); ok {
+			t.Errorf("RankSelect.Select1(%d): should not be found", ones)
+		}
+		if _, ok := rs.Select0(zeros); ok {
+			t.Errorf("RankSelect.Select0(%d): should not be found", zeros)
+		}
+	})
+
+	t.Run("Sparse", func(t *testing.T) {
+		t.Parallel()
+
+		// samples far apart so that select has many blocks to search
+		const gap = 1000
+		var b BitUint64
+		for j := 0; j < 2000*gap; j += gap {
+			b.Set(j, true)
+		}
+		rs := NewRankSelect(b)
+		for k := range rs.Count() {
+			if got, ok := rs.Select1(k); !ok || got != k*gap {
+				t.Errorf("RankSelect.Select1(%d): got=%d;want=%d", k, got, k*gap)
+				return
+			}
+		}
+	})
+
+	t.Run("ReadFrom", func(t *testing.T) {
+		t.Parallel()
+
+		var b BitUint64
+		for j := 0; j < M; j += 11 {
+			b.Set(j, true)
+		}
+		rs := NewRankSelect(b)
+
+		var buf bytes.Buffer
+		nw, err := rs.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("RankSelect.WriteTo: %v", err)
+		}
+		var rs2 RankSelect
+		nr, err := rs2.ReadFrom(&buf)
+		if err != nil {
+			t.Errorf("RankSelect.ReadFrom: %v", err)
+		}
+		if nr != nw {
+			t.Errorf("RankSelect.ReadFrom: n=%d;want=%d", nr, nw)
+		}
+		for k := range rs.Count() {
+			got, _ := rs2.Select1(k)
+			if want, _ := rs.Select1(k); got != want {
+				t.Errorf("RankSelect.Select1(%d): got=%d;want=%d", k, got, want)
+				return
+			}
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		rs := NewRankSelect(BitUint64{})
+		var buf bytes.Buffer
+		_, err := rs.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("RankSelect.WriteTo: %v", err)
+		}
+		var rs2 RankSelect
+		_, err = rs2.ReadFrom(&buf)
+		if err != nil {
+			t.Errorf("RankSelect.ReadFrom: %v", err)
+		}
+		if rs2.Len() != 0 || rs2.Count() != 0 || rs2.Rank1(10) != 0 {
+			t.Errorf("RankSelect: got len=%d count=%d;want empty", rs2.Len(), rs2.Count())
+		}
+		if _, ok := rs2.Select1(0); ok {
+			t.Error("RankSelect.Select1(0): found a bit in an empty vector")
+		}
+	})
+	t.Run("Corrupt", func(t *testing.T) {
+		t.Parallel()
+
+		rs := NewRankSelect(a)
+		var buf bytes.Buffer
+		_, err := rs.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("RankSelect.WriteTo: %v", err)
+		}
+		p := buf.Bytes()
+		p[len(p)-1] ^= 0xff // last sample
+
+		var rs2 RankSelect
+		if _, err = rs2.ReadFrom(bytes.NewReader(p)); err == nil {
+			t.Error("RankSelect.ReadFrom: got nil error for a corrupted directory")
+		}
+	})
+}
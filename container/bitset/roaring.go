@@ -0,0 +1,655 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// Serialization constants from the Roaring format specification.
+//
+// See more: https://github.com/RoaringBitmap/RoaringFormatSpec
+const (
+	serialCookieNoRunContainer = 12346
+	serialCookie               = 12347
+	noOffsetThreshold          = 4
+)
+
+// arrayMaxSize is the largest cardinality stored as an array container.
+const arrayMaxSize = 4096
+
+// ErrRoaringFormat is returned by [Roaring.ReadFrom] when the input is not
+// a valid Roaring bitmap.
+var ErrRoaringFormat = errors.New("invalid roaring bitmap format")
+
+// Roaring is a compressed bit set of uint32 values. Values are partitioned
+// by their high 16 bits into containers that are stored as sorted arrays,
+// bitmaps or runs, whichever suits the density of the partition.
+//
+// The zero value is an empty set ready to use.
+type Roaring struct {
+	keys       []uint16 // sorted
+	containers []container
+}
+
+// Add adds x to the set.
+func (r *Roaring) Add(x uint32) {
+	hi, lo := uint16(x>>16), uint16(x)
+	i, ok := slices.BinarySearch(r.keys, hi)
+	if !ok {
+		r.keys = slices.Insert(r.keys, i, hi)
+		r.containers = slices.Insert(r.containers, i, container(&arrayContainer{}))
+	}
+	r.containers[i] = r.containers[i].add(lo)
+}
+
+// Remove removes x from the set.
+func (r *Roaring) Remove(x uint32) {
+	hi, lo := uint16(x>>16), uint16(x)
+	i, ok := slices.BinarySearch(r.keys, hi)
+	if !ok {
+		return
+	}
+	c := r.containers[i].remove(lo)
+	if c.card() == 0 {
+		r.keys = slices.Delete(r.keys, i, i+1)
+		r.containers = slices.Delete(r.containers, i, i+1)
+		return
+	}
+	r.containers[i] = c
+}
+
+// Has checks if x is in the set.
+func (r *Roaring) Has(x uint32) bool {
+	i, ok := slices.BinarySearch(r.keys, uint16(x>>16))
+	return ok && r.containers[i].has(uint16(x))
+}
+
+// Count returns the number of values in the set.
+func (r *Roaring) Count() int {
+	var n int
+	for _, c := range r.containers {
+		n += c.card()
+	}
+	return n
+}
+
+// All returns an iterator over the values in the set in ascending order.
+func (r *Roaring) All() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for i, c := range r.containers {
+			hi := uint32(r.keys[i]) << 16
+			for lo := range c.all() {
+				if !yield(hi | uint32(lo)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Clone returns a deep copy of the set.
+func (r *Roaring) Clone() *Roaring {
+	s := &Roaring{
+		keys:       slices.Clone(r.keys),
+		containers: make([]container, len(r.containers)),
+	}
+	for i, c := range r.containers {
+		s.containers[i] = c.clone()
+	}
+	return s
+}
+
+// And returns a new set containing the values in both r and s.
+func (r *Roaring) And(s *Roaring) *Roaring {
+	return r.merge(s, opAnd, false, false)
+}
+
+// Or returns a new set containing the values in either r or s.
+func (r *Roaring) Or(s *Roaring) *Roaring {
+	return r.merge(s, opOr, true, true)
+}
+
+// Xor returns a new set containing the values in exactly one of r and s.
+func (r *Roaring) Xor(s *Roaring) *Roaring {
+	return r.merge(s, opXor, true, true)
+}
+
+// AndNot returns a new set containing the values in r but not in s.
+func (r *Roaring) AndNot(s *Roaring) *Roaring {
+	return r.merge(s, opAndNot, true, false)
+}
+
+// merge walks the keys of r and s in order, combining containers that share
+// a key with op and copying those only found on the kept side.
+func (r *Roaring) merge(s *Roaring, op setOp, keepR, keepS bool) *Roaring {
+	d := new(Roaring)
+	push := func(key uint16, c container) {
+		if c != nil && c.card() > 0 {
+			d.keys = append(d.keys, key)
+			d.containers = append(d.containers, c)
+		}
+	}
+	i, j := 0, 0
+	for i < len(r.keys) && j < len(s.keys) {
+		switch {
+		case r.keys[i] < s.keys[j]:
+			if keepR {
+				push(r.keys[i], r.containers[i].clone())
+			}
+			i++
+		case r.keys[i] > s.keys[j]:
+			if keepS {
+				push(s.keys[j], s.containers[j].clone())
+			}
+			j++
+		default:
+			push(r.keys[i], combine(r.containers[i], s.containers[j], op))
+			i++
+			j++
+		}
+	}
+	for ; keepR && i < len(r.keys); i++ {
+		push(r.keys[i], r.containers[i].clone())
+	}
+	for ; keepS && j < len(s.keys); j++ {
+		push(s.keys[j], s.containers[j].clone())
+	}
+	return d
+}
+
+// RunOptimize converts each container to run-length encoding where that is
+// the most compact representation, and back again where it is not.
+func (r *Roaring) RunOptimize() {
+	for i, c := range r.containers {
+		w := words(c)
+		runs := runsOf(w)
+		if 2+4*len(runs) < canonical(w).size() {
+			r.containers[i] = &runContainer{runs: runs}
+		} else if _, ok := c.(*runContainer); ok {
+			r.containers[i] = canonical(w)
+		}
+	}
+}
+
+// WriteTo implements io.WriterTo using the portable Roaring format.
+func (r *Roaring) WriteTo(w io.Writer) (n int64, err error) {
+	size := len(r.containers)
+	hasRun := slices.ContainsFunc(r.containers, func(c container) bool {
+		_, ok := c.(*runContainer)
+		return ok
+	})
+
+	var header []any
+	offset := 4 + 4*size
+	if hasRun {
+		runs := make([]byte, (size+7)/8)
+		for i, c := range r.containers {
+			if _, ok := c.(*runContainer); ok {
+				runs[i/8] |= 1 << (i % 8)
+			}
+		}
+		header = append(header, uint32(serialCookie|(size-1)<<16), runs)
+		offset += len(runs)
+	} else {
+		header = append(header, uint32(serialCookieNoRunContainer), uint32(size))
+		offset += 4
+	}
+	desc := make([]uint16, 0, 2*size)
+	for i, c := range r.containers {
+		desc = append(desc, r.keys[i], uint16(c.card()-1))
+	}
+	header = append(header, desc)
+	if !hasRun || size >= noOffsetThreshold {
+		offset += 4 * size
+		offsets := make([]uint32, size)
+		for i, c := range r.containers {
+			offsets[i] = uint32(offset)
+			offset += c.size()
+		}
+		header = append(header, offsets)
+	}
+
+	for _, v := range header {
+		err = binary.Write(w, binary.LittleEndian, v)
+		if err != nil {
+			return n, fmt.Errorf("cannot encode roaring header: %w", err)
+		}
+		n += int64(binary.Size(v))
+	}
+	for i, c := range r.containers {
+		nw, err := c.writeTo(w)
+		n += nw
+		if err != nil {
+			return n, fmt.Errorf("cannot encode container %d: %w", r.keys[i], err)
+		}
+	}
+	return n, nil
+}
+
+// ReadFrom implements io.ReaderFrom using the portable Roaring format.
+func (r *Roaring) ReadFrom(rd io.Reader) (n int64, err error) {
+	read := func(v any) error {
+		err := binary.Read(rd, binary.LittleEndian, v)
+		if err == nil {
+			n += int64(binary.Size(v))
+		}
+		return err
+	}
+
+	var cookie uint32
+	err = read(&cookie)
+	if err != nil {
+		return n, fmt.Errorf("cannot decode roaring cookie: %w", err)
+	}
+	var size int
+	var runs []byte
+	switch {
+	case cookie&0xFFFF == serialCookie:
+		size = int(cookie>>16) + 1
+		runs = make([]byte, (size+7)/8)
+		err = read(runs)
+		if err != nil {
+			return n, fmt.Errorf("cannot decode run flags: %w", err)
+		}
+	case cookie == serialCookieNoRunContainer:
+		var sz uint32
+		err = read(&sz)
+		if err != nil {
+			return n, fmt.Errorf("cannot decode number of containers: %w", err)
+		}
+		if sz > 1<<16 {
+			return n, fmt.Errorf("%w: %d containers", ErrRoaringFormat, sz)
+		}
+		size = int(sz)
+	default:
+		return n, fmt.Errorf("%w: unknown cookie %d", ErrRoaringFormat, cookie)
+	}
+
+	desc := make([]uint16, 2*size)
+	err = read(desc)
+	if err != nil {
+		return n, fmt.Errorf("cannot decode roaring header: %w", err)
+	}
+	if runs == nil || size >= noOffsetThreshold {
+		err = read(make([]uint32, size)) // offsets are not needed when reading in order
+		if err != nil {
+			return n, fmt.Errorf("cannot decode roaring offsets: %w", err)
+		}
+	}
+
+	// decoded aside so that r is left untouched by an error
+	keys := make([]uint16, size)
+	containers := make([]container, size)
+	for i := range size {
+		key, card := desc[2*i], int(desc[2*i+1])+1
+		if i > 0 && key <= keys[i-1] {
+			return n, fmt.Errorf("%w: keys out of order", ErrRoaringFormat)
+		}
+		keys[i] = key
+		switch {
+		case runs != nil && runs[i/8]&(1<<(i%8)) != 0:
+			var nr uint16
+			err = read(&nr)
+			if err != nil {
+				return n, fmt.Errorf("cannot decode size of container %d: %w", key, err)
+			}
+			p := make([]uint16, 2*int(nr))
+			err = read(p)
+			if err != nil {
+				return n, fmt.Errorf("cannot decode container %d: %w", key, err)
+			}
+			c := &runContainer{runs: make([]interval, nr)}
+			for j := range c.runs {
+				if int(p[2*j])+int(p[2*j+1]) > 0xFFFF {
+					return n, fmt.Errorf("%w: run overflows container %d", ErrRoaringFormat, key)
+				}
+				if j > 0 && p[2*j] <= c.runs[j-1].last {
+					return n, fmt.Errorf("%w: runs out of order in container %d", ErrRoaringFormat, key)
+				}
+				c.runs[j] = interval{p[2*j], p[2*j] + p[2*j+1]}
+			}
+			containers[i] = c
+		case card <= arrayMaxSize:
+			c := &arrayContainer{vals: make([]uint16, card)}
+			err = read(c.vals)
+			if err != nil {
+				return n, fmt.Errorf("cannot decode container %d: %w", key, err)
+			}
+			for j := 1; j < len(c.vals); j++ {
+				if c.vals[j] <= c.vals[j-1] {
+					return n, fmt.Errorf("%w: values out of order in container %d", ErrRoaringFormat, key)
+				}
+			}
+			containers[i] = c
+		default:
+			c := new(bitmapContainer)
+			err = read(&c.words)
+			if err != nil {
+				return n, fmt.Errorf("cannot decode container %d: %w", key, err)
+			}
+			c.n = BitUint64(c.words[:]).Count()
+			containers[i] = c
+		}
+	}
+	r.keys, r.containers = keys, containers
+	return n, nil
+}
+
+type setOp int
+
+const (
+	opAnd setOp = iota
+	opOr
+	opXor
+	opAndNot
+)
+
+// container holds the low 16 bits of the values that share a key.
+// Mutating methods return the container to use from then on, which may
+// differ from the receiver when the representation changes.
+type container interface {
+	has(x uint16) bool
+	add(x uint16) container
+	remove(x uint16) container
+	card() int
+	all() iter.Seq[uint16]
+	clone() container
+	size() int // serialized size in bytes
+	writeTo(w io.Writer) (int64, error)
+}
+
+// combine applies op to a and b. Two arrays are merged directly, any other
+// pairing goes through a bitmap.
+func combine(a, b container, op setOp) container {
+	if a, ok := a.(*arrayContainer); ok {
+		if b, ok := b.(*arrayContainer); ok {
+			vals := mergeArrays(a.vals, b.vals, op)
+			if len(vals) > arrayMaxSize {
+				return (&arrayContainer{vals: vals}).bitmap()
+			}
+			return &arrayContainer{vals: vals}
+		}
+	}
+	wa, wb := words(a), words(b)
+	for i := range wa {
+		switch op {
+		case opAnd:
+			wa[i] &= wb[i]
+		case opOr:
+			wa[i] |= wb[i]
+		case opXor:
+			wa[i] ^= wb[i]
+		case opAndNot:
+			wa[i] &^= wb[i]
+		}
+	}
+	return canonical(wa)
+}
+
+func mergeArrays(a, b []uint16, op setOp) []uint16 {
+	out := make([]uint16, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			if op != opAnd {
+				out = append(out, a[i])
+			}
+			i++
+		case a[i] > b[j]:
+			if op == opOr || op == opXor {
+				out = append(out, b[j])
+			}
+			j++
+		default:
+			if op == opAnd || op == opOr {
+				out = append(out, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	if op != opAnd {
+		out = append(out, a[i:]...)
+	}
+	if op == opOr || op == opXor {
+		out = append(out, b[j:]...)
+	}
+	return out
+}
+
+// words returns a copy of the values in c as a bitmap.
+func words(c container) *[1024]uint64 {
+	if b, ok := c.(*bitmapContainer); ok {
+		w := b.words
+		return &w
+	}
+	var w [1024]uint64
+	for x := range c.all() {
+		w[x/64] |= 1 << (x % 64)
+	}
+	return &w
+}
+
+// canonical returns w as an array or bitmap container depending on its
+// cardinality.
+func canonical(w *[1024]uint64) container {
+	b := &bitmapContainer{words: *w, n: BitUint64(w[:]).Count()}
+	if b.n <= arrayMaxSize {
+		return b.array()
+	}
+	return b
+}
+
+func runsOf(w *[1024]uint64) []interval {
+	var runs []interval
+	b := BitUint64(w[:])
+	for i, ok := b.NextSet(0); ok; i, ok = b.NextSet(i) {
+		j := b.NextClear(i)
+		runs = append(runs, interval{uint16(i), uint16(j - 1)})
+		i = j
+	}
+	return runs
+}
+
+type arrayContainer struct {
+	vals []uint16 // sorted
+}
+
+func (a *arrayContainer) has(x uint16) bool {
+	_, ok := slices.BinarySearch(a.vals, x)
+	return ok
+}
+
+func (a *arrayContainer) add(x uint16) container {
+	i, ok := slices.BinarySearch(a.vals, x)
+	if ok {
+		return a
+	}
+	if len(a.vals) == arrayMaxSize {
+		return a.bitmap().add(x)
+	}
+	a.vals = slices.Insert(a.vals, i, x)
+	return a
+}
+
+func (a *arrayContainer) remove(x uint16) container {
+	if i, ok := slices.BinarySearch(a.vals, x); ok {
+		a.vals = slices.Delete(a.vals, i, i+1)
+	}
+	return a
+}
+
+func (a *arrayContainer) card() int { return len(a.vals) }
+
+func (a *arrayContainer) all() iter.Seq[uint16] { return slices.Values(a.vals) }
+
+func (a *arrayContainer) clone() container { return &arrayContainer{vals: slices.Clone(a.vals)} }
+
+func (a *arrayContainer) size() int { return 2 * len(a.vals) }
+
+func (a *arrayContainer) writeTo(w io.Writer) (int64, error) {
+	err := binary.Write(w, binary.LittleEndian, a.vals)
+	if err != nil {
+		return 0, err
+	}
+	return int64(a.size()), nil
+}
+
+func (a *arrayContainer) bitmap() *bitmapContainer {
+	b := &bitmapContainer{n: len(a.vals)}
+	for _, x := range a.vals {
+		b.words[x/64] |= 1 << (x % 64)
+	}
+	return b
+}
+
+type bitmapContainer struct {
+	words [1024]uint64
+	n     int // cardinality
+}
+
+func (b *bitmapContainer) has(x uint16) bool {
+	return b.words[x/64]&(1<<(x%64)) != 0
+}
+
+func (b *bitmapContainer) add(x uint16) container {
+	w, m := &b.words[x/64], uint64(1)<<(x%64)
+	if *w&m == 0 {
+		*w |= m
+		b.n++
+	}
+	return b
+}
+
+func (b *bitmapContainer) remove(x uint16) container {
+	w, m := &b.words[x/64], uint64(1)<<(x%64)
+	if *w&m != 0 {
+		*w &^= m
+		b.n--
+	}
+	if b.n <= arrayMaxSize {
+		return b.array()
+	}
+	return b
+}
+
+func (b *bitmapContainer) card() int { return b.n }
+
+func (b *bitmapContainer) all() iter.Seq[uint16] {
+	return func(yield func(uint16) bool) {
+		for x := range BitUint64(b.words[:]).All() {
+			if !yield(uint16(x)) {
+				return
+			}
+		}
+	}
+}
+
+func (b *bitmapContainer) clone() container { c := *b; return &c }
+
+func (b *bitmapContainer) size() int { return 8 * len(b.words) }
+
+func (b *bitmapContainer) writeTo(w io.Writer) (int64, error) {
+	err := binary.Write(w, binary.LittleEndian, &b.words)
+	if err != nil {
+		return 0, err
+	}
+	return int64(b.size()), nil
+}
+
+func (b *bitmapContainer) array() *arrayContainer {
+	a := &arrayContainer{vals: make([]uint16, 0, b.n)}
+	for i, w := range b.words {
+		for w != 0 {
+			a.vals = append(a.vals, uint16(64*i+bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+	return a
+}
+
+// interval is an inclusive range of values.
+type interval struct {
+	start, last uint16
+}
+
+type runContainer struct {
+	runs []interval // sorted, non-overlapping
+}
+
+func (r *runContainer) has(x uint16) bool {
+	_, ok := slices.BinarySearchFunc(r.runs, x, func(v interval, x uint16) int {
+		switch {
+		case v.last < x:
+			return -1
+		case v.start > x:
+			return 1
+		}
+		return 0
+	})
+	return ok
+}
+
+// add and remove fall back to an array or bitmap; run containers are
+// only created by [Roaring.RunOptimize] and [Roaring.ReadFrom].
+func (r *runContainer) add(x uint16) container {
+	if r.has(x) {
+		return r
+	}
+	return canonical(words(r)).add(x)
+}
+
+func (r *runContainer) remove(x uint16) container {
+	if !r.has(x) {
+		return r
+	}
+	return canonical(words(r)).remove(x)
+}
+
+func (r *runContainer) card() int {
+	var n int
+	for _, v := range r.runs {
+		n += int(v.last-v.start) + 1
+	}
+	return n
+}
+
+func (r *runContainer) all() iter.Seq[uint16] {
+	return func(yield func(uint16) bool) {
+		for _, v := range r.runs {
+			for x := int(v.start); x <= int(v.last); x++ {
+				if !yield(uint16(x)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *runContainer) clone() container { return &runContainer{runs: slices.Clone(r.runs)} }
+
+func (r *runContainer) size() int { return 2 + 4*len(r.runs) }
+
+func (r *runContainer) writeTo(w io.Writer) (int64, error) {
+	p := make([]uint16, 1, 1+2*len(r.runs))
+	p[0] = uint16(len(r.runs))
+	for _, v := range r.runs {
+		p = append(p, v.start, v.last-v.start)
+	}
+	err := binary.Write(w, binary.LittleEndian, p)
+	if err != nil {
+		return 0, err
+	}
+	return int64(r.size()), nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bitset_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	. "go.adoublef.dev/container/bitset"
+)
+
+func TestRoaring(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Count", func(t *testing.T) {
+		t.Parallel()
+
+		var r Roaring
+		want := make(map[uint32]bool)
+		rng := rand.New(rand.NewPCG(1, 2))
+		for range M {
+			x := rng.Uint32N(1 << 20)
+			r.Add(x)
+			want[x] = true
+		}
+		for x := range uint32(1 << 12) {
+			r.Add(x) // dense
+			want[x] = true
+		}
+		if got, want := r.Count(), len(want); got != want {
+			t.Errorf("Roaring.Count: got=%d;want=%d", got, want)
+		}
+		for x := range want {
+			if !r.Has(x) {
+				t.Errorf("Roaring.Has: missing %d", x)
+				return
+			}
+		}
+		if !slices.IsSorted(slices.Collect(r.All())) {
+			t.Errorf("Roaring.All: values not in ascending order")
+		}
+
+		for x := range want {
+			r.Remove(x)
+		}
+		if got := r.Count(); got != 0 {
+			t.Errorf("Roaring.Count: got=%d;want=%d", got, 0)
+		}
+	})
+
+	t.Run("Algebra", func(t *testing.T) {
+		t.Parallel()
+
+		var a, b Roaring
+		for x := uint32(0); x < 200000; x += 2 {
+			a.Add(x)
+		}
+		for x := uint32(100000); x < 300000; x += 3 {
+			b.Add(x)
+		}
+		b.RunOptimize()
+
+		for _, tc := range []struct {
+			name string
+			got  *Roaring
+			f    func(x, y bool) bool
+		}{
+			{"And", a.And(&b), func(x, y bool) bool { return x && y }},
+			{"Or", a.Or(&b), func(x, y bool) bool { return x || y }},
+			{"Xor", a.Xor(&b), func(x, y bool) bool { return x != y }},
+			{"AndNot", a.AndNot(&b), func(x, y bool) bool { return x && !y }},
+		} {
+			var n int
+			for x := range uint32(310000) {
+				want := tc.f(a.Has(x), b.Has(x))
+				if got := tc.got.Has(x); got != want {
+					t.Errorf("Roaring.%s: value %d got=%t;want=%t", tc.name, x, got, want)
+					break
+				}
+				if want {
+					n++
+				}
+			}
+			if got := tc.got.Count(); got != n {
+				t.Errorf("Roaring.%s: Count got=%d;want=%d", tc.name, got, n)
+			}
+		}
+	})
+
+	t.Run("WriteTo", func(t *testing.T) {
+		t.Parallel()
+
+		// vectors follow https://github.com/RoaringBitmap/RoaringFormatSpec
+		var a Roaring
+		for _, x := range []uint32{1, 2, 3, 1000} {
+			a.Add(x)
+		}
+		var b Roaring
+		for x := range uint32(100) {
+			b.Add(x)
+		}
+		b.RunOptimize()
+
+		for _, tc := range []struct {
+			name string
+			r    *Roaring
+			want string
+		}{
+			{"Array", &a, "3a300000" + "01000000" + "00000300" + "10000000" + "0100020003" + "00e803"},
+			{"Run", &b, "3b300000" + "01" + "00006300" + "0100" + "00006300"},
+		} {
+			var buf bytes.Buffer
+			n, err := tc.r.WriteTo(&buf)
+			if err != nil {
+				t.Errorf("Roaring.WriteTo: %v", err)
+			}
+			want, _ := hex.DecodeString(tc.want)
+			if got := buf.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("%s: Roaring.WriteTo: got=%x;want=%x", tc.name, got, want)
+			}
+			if n != int64(len(want)) {
+				t.Errorf("%s: Roaring.WriteTo: n=%d;want=%d", tc.name, n, len(want))
+			}
+		}
+	})
+
+	t.Run("ReadFrom", func(t *testing.T) {
+		t.Parallel()
+
+		var a Roaring
+		for x := uint32(0); x < 1<<18; x += 7 {
+			a.Add(x) // bitmap containers
+		}
+		for x := uint32(1 << 20); x < 1<<20+5000; x++ {
+			a.Add(x) // run container
+		}
+		for _, x := range []uint32{1 << 24, 1<<24 + 9, 1 << 30} {
+			a.Add(x) // array containers
+		}
+		a.RunOptimize()
+
+		var buf bytes.Buffer
+		nw, err := a.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("Roaring.WriteTo: %v", err)
+		}
+
+		var b Roaring
+		nr, err := b.ReadFrom(&buf)
+		if err != nil {
+			t.Errorf("Roaring.ReadFrom: %v", err)
+		}
+		if nr != nw {
+			t.Errorf("Roaring.ReadFrom: n=%d;want=%d", nr, nw)
+		}
+		if !slices.Equal(slices.Collect(a.All()), slices.Collect(b.All())) {
+			t.Errorf("Roaring.ReadFrom: values differ")
+		}
+	})
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+
+		var valid bytes.Buffer
+		var a Roaring
+		for x := range uint32(1000) {
+			a.Add(x << 12) // array containers
+		}
+		if _, err := a.WriteTo(&valid); err != nil {
+			t.Errorf("Roaring.WriteTo: %v", err)
+		}
+
+		for name, p := range map[string][]byte{
+			"Truncated": valid.Bytes()[:valid.Len()/2],
+			// one array container holding 5 then 3
+			"Array": le(uint32(12346), uint32(1), uint16(0), uint16(1), uint32(0), uint16(5), uint16(3)),
+			// one run container holding 10-20 then 15-16
+			"Runs": le(uint32(12347), uint8(1), uint16(0), uint16(12), uint16(2), uint16(10), uint16(10), uint16(15), uint16(1)),
+		} {
+			t.Run(name, func(t *testing.T) {
+				var b Roaring
+				b.Add(1)
+				if _, err := b.ReadFrom(bytes.NewReader(p)); err == nil {
+					t.Error("Roaring.ReadFrom: got nil error")
+				}
+				// left as it was
+				if !b.Has(1) || b.Count() != 1 {
+					t.Errorf("Roaring.ReadFrom: modified the set on error")
+				}
+			})
+		}
+	})
+}
+
+// le encodes vv in little endian order.
+func le(vv ...any) []byte {
+	var buf bytes.Buffer
+	for _, v := range vv {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lru
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+const (
+	arcRecent   = iota // T1
+	arcFrequent        // T2
+)
+
+// ARC is an Adaptive Replacement Cache. It balances between entries seen
+// once and entries seen more than once, adapting the split between them
+// using the history of recently evicted keys.
+// It is not safe for concurrent access.
+//
+// See more: https://www.usenix.org/legacy/events/fast03/tech/full_papers/megiddo/megiddo.pdf
+type ARC[K comparable, V any] struct {
+	// TTL is the time to live of entries added with [ARC.Add].
+	// Zero means entries do not expire.
+	TTL time.Duration
+
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	OnEvicted func(key K, value V, reason Reason)
+
+	size     int
+	p        int // target size of the recent segment
+	resident resident[K, V]
+	b1, b2   ghost[K] // keys evicted from the recent and frequent segments
+}
+
+// NewARC creates a new [ARC] holding at most size entries.
+func NewARC[K comparable, V any](size int) *ARC[K, V] {
+	assert(size > 0, "size must be positive")
+
+	return &ARC[K, V]{
+		size:     size,
+		resident: newResident[K, V](2),
+		b1:       newGhost[K](),
+		b2:       newGhost[K](),
+	}
+}
+
+// Add adds a value to the cache that expires after the default [ARC.TTL].
+func (c *ARC[K, V]) Add(key K, value V) {
+	c.AddWithTTL(key, value, c.TTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl.
+// If ttl is not positive the value does not expire.
+func (c *ARC[K, V]) AddWithTTL(key K, value V, ttl time.Duration) {
+	if ele, e := c.resident.get(key); e != nil {
+		e.value = value
+		e.setTTL(ttl)
+		c.resident.move(ele, arcFrequent)
+		return
+	}
+
+	switch {
+	case c.b1.has(key):
+		c.p = min(c.size, c.p+max(1, c.b2.len()/c.b1.len()))
+		c.b1.remove(key)
+		c.replace(false)
+		c.resident.push(arcFrequent, newEntry(key, value, ttl))
+		return
+	case c.b2.has(key):
+		c.p = max(0, c.p-max(1, c.b1.len()/c.b2.len()))
+		c.b2.remove(key)
+		c.replace(true)
+		c.resident.push(arcFrequent, newEntry(key, value, ttl))
+		return
+	}
+
+	t1 := c.resident.len(arcRecent)
+	if l1 := t1 + c.b1.len(); l1 >= c.size {
+		if t1 < c.size {
+			c.b1.removeOldest()
+			c.replace(false)
+		} else {
+			c.removeElement(c.resident.back(arcRecent), Capacity)
+		}
+	} else if total := l1 + c.resident.len(arcFrequent) + c.b2.len(); total >= c.size {
+		if total >= 2*c.size {
+			c.b2.removeOldest()
+		}
+		c.replace(false)
+	}
+	c.resident.push(arcRecent, newEntry(key, value, ttl))
+}
+
+// replace makes room for a new entry once the cache is full, moving the
+// least recently used entry of one segment into its ghost list.
+func (c *ARC[K, V]) replace(inB2 bool) {
+	if len(c.resident.m) < c.size {
+		return
+	}
+	c.evict(inB2)
+}
+
+func (c *ARC[K, V]) evict(inB2 bool) {
+	t1 := c.resident.len(arcRecent)
+	if t1 > 0 && (t1 > c.p || (inB2 && t1 == c.p) || c.resident.len(arcFrequent) == 0) {
+		e := c.removeElement(c.resident.back(arcRecent), Capacity)
+		c.b1.push(e.key)
+	} else if ele := c.resident.back(arcFrequent); ele != nil {
+		e := c.removeElement(ele, Capacity)
+		c.b2.push(e.key)
+	}
+}
+
+// Get looks up a key's value from the cache.
+// An expired entry is removed and reported as a miss.
+func (c *ARC[K, V]) Get(key K) (V, bool) {
+	ele, e := c.resident.get(key)
+	if e == nil {
+		return *new(V), false
+	}
+	if e.expired(time.Now()) {
+		c.removeElement(ele, Expired)
+		return *new(V), false
+	}
+	c.resident.move(ele, arcFrequent)
+	return e.value, true
+}
+
+// Peek looks up a key's value from the cache without updating
+// its recency. An expired entry is reported as a miss but not removed.
+func (c *ARC[K, V]) Peek(key K) (V, bool) { return c.resident.peek(key) }
+
+// Remove removes the provided key from the cache.
+func (c *ARC[K, V]) Remove(key K) {
+	if ele, e := c.resident.get(key); e != nil {
+		c.removeElement(ele, Removed)
+	}
+}
+
+// RemoveOldest removes the entry the policy would evict next.
+func (c *ARC[K, V]) RemoveOldest() { c.evict(false) }
+
+// RemoveExpired removes all expired items from the cache and
+// returns how many were removed.
+func (c *ARC[K, V]) RemoveExpired() int {
+	ee := c.resident.expired(time.Now())
+	for _, ele := range ee {
+		c.removeElement(ele, Expired)
+	}
+	return len(ee)
+}
+
+// Backward iterates over the unexpired entries seen once and then those
+// seen more than once, each from least to most recently used.
+func (c *ARC[K, V]) Backward() iter.Seq2[K, V] {
+	return c.resident.backward(arcRecent, arcFrequent)
+}
+
+// Len returns the number of items in the cache, including
+// expired items that have not yet been removed.
+func (c *ARC[K, V]) Len() int { return len(c.resident.m) }
+
+// Clear purges all stored items from the cache.
+func (c *ARC[K, V]) Clear() {
+	c.resident.clear(c.OnEvicted)
+	c.b1.clear()
+	c.b2.clear()
+	c.p = 0
+}
+
+func (c *ARC[K, V]) removeElement(ele *list.Element, reason Reason) *entry[K, V] {
+	e := c.resident.remove(ele)
+	if c.OnEvicted != nil {
+		c.OnEvicted(e.key, e.value, reason)
+	}
+	return e
+}
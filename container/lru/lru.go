@@ -5,7 +5,39 @@
 
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+// Reason describes why an entry was purged from the cache.
+type Reason int
+
+const (
+	// Removed means the entry was removed by the caller.
+	Removed Reason = iota
+	// Capacity means the entry was evicted to make room for another.
+	Capacity
+	// Expired means the entry outlived its time to live.
+	Expired
+	// Cleared means the cache was cleared.
+	Cleared
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Removed:
+		return "removed"
+	case Capacity:
+		return "capacity"
+	case Expired:
+		return "expired"
+	case Cleared:
+		return "cleared"
+	}
+	return "unknown"
+}
 
 // LRU is an LRU cache. It is not safe for concurrent access.
 type LRU[K comparable, V any] struct {
@@ -13,17 +45,50 @@ type LRU[K comparable, V any] struct {
 	// an item is evicted. Zero means no limit.
 	MaxEntries int
 
+	// MaxCost is the maximum total cost of cache entries before
+	// the oldest are evicted. Zero means no limit.
+	MaxCost int64
+
+	// CostFunc optionally reports the cost of an entry.
+	// If nil, every entry costs one.
+	CostFunc func(key K, value V) int64
+
+	// TTL is the time to live of entries added with [LRU.Add].
+	// Zero means entries do not expire.
+	TTL time.Duration
+
 	// OnEvicted optionally specifies a callback function to be
 	// executed when an entry is purged from the cache.
-	OnEvicted func(key K, value V)
+	OnEvicted func(key K, value V, reason Reason)
 
-	ll *list.List
-	m  map[K]*list.Element
+	ll   *list.List
+	m    map[K]*list.Element
+	cost int64 // of all entries
 }
 
 type entry[K comparable, V any] struct {
-	key   K
-	value V
+	key     K
+	value   V
+	expires time.Time // zero means never
+	seg     int       // segment holding the entry, see [resident]
+	cost    int64
+}
+
+func newEntry[K comparable, V any](key K, value V, ttl time.Duration) *entry[K, V] {
+	e := &entry[K, V]{key: key, value: value}
+	e.setTTL(ttl)
+	return e
+}
+
+func (e *entry[K, V]) setTTL(ttl time.Duration) {
+	e.expires = time.Time{}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
 }
 
 // New creates a new [LRU].
@@ -37,43 +102,157 @@ func New[K comparable, V any](maxEntries int) *LRU[K, V] {
 	}
 }
 
-// Add adds a value to the cache.
+// Add adds a value to the cache that expires after the default [LRU.TTL].
 func (c *LRU[K, V]) Add(key K, value V) {
+	c.AddWithTTL(key, value, c.TTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl.
+// If ttl is not positive the value does not expire.
+func (c *LRU[K, V]) AddWithTTL(key K, value V, ttl time.Duration) {
 	if c.m == nil {
 		c.m = make(map[K]*list.Element)
 		c.ll = list.New()
 	}
+	cost := c.costOf(key, value)
 	if ee, ok := c.m[key]; ok {
 		c.ll.MoveToFront(ee)
-		ee.Value.(*entry[K, V]).value = value
-		return
+		e := ee.Value.(*entry[K, V])
+		e.value = value
+		e.setTTL(ttl)
+		c.cost += cost - e.cost
+		e.cost = cost
+	} else {
+		e := newEntry(key, value, ttl)
+		e.cost = cost
+		c.m[key] = c.ll.PushFront(e)
+		c.cost += cost
 	}
-	ele := c.ll.PushFront(&entry[K, V]{key, value})
-	c.m[key] = ele
-	if c.MaxEntries != 0 && c.ll.Len() > c.MaxEntries {
+	for c.ll.Len() > 0 && (c.MaxEntries != 0 && c.ll.Len() > c.MaxEntries || c.MaxCost != 0 && c.cost > c.MaxCost) {
 		c.RemoveOldest()
 	}
 }
 
+func (c *LRU[K, V]) costOf(key K, value V) int64 {
+	if c.CostFunc == nil {
+		return 1
+	}
+	return c.CostFunc(key, value)
+}
+
 // Get looks up a key's value from the cache.
+// An expired entry is removed and reported as a miss.
 func (c *LRU[K, V]) Get(key K) (V, bool) {
 	if c.m == nil {
 		return *new(V), false
 	}
 	if ele, hit := c.m[key]; hit {
+		if ele.Value.(*entry[K, V]).expired(time.Now()) {
+			c.removeElement(ele, Expired)
+			return *new(V), false
+		}
 		c.ll.MoveToFront(ele)
 		return ele.Value.(*entry[K, V]).value, true
 	}
 	return *new(V), false
 }
 
+// Peek looks up a key's value from the cache without updating
+// its recency. An expired entry is reported as a miss but not removed.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	if c.m == nil {
+		return *new(V), false
+	}
+	if ele, hit := c.m[key]; hit {
+		if e := ele.Value.(*entry[K, V]); !e.expired(time.Now()) {
+			return e.value, true
+		}
+	}
+	return *new(V), false
+}
+
+// Contains reports whether key is in the cache without updating its recency.
+func (c *LRU[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// GetOldest returns the least recently used entry without updating
+// its recency.
+func (c *LRU[K, V]) GetOldest() (K, V, bool) {
+	for k, v := range c.Backward() {
+		return k, v, true
+	}
+	return *new(K), *new(V), false
+}
+
+// All returns an iterator over the entries in the cache from most to
+// least recently used. Expired entries that have not yet been removed
+// are skipped, and iterating does not update recency.
+func (c *LRU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if c.m == nil {
+			return
+		}
+		now := time.Now()
+		for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+			e := ele.Value.(*entry[K, V])
+			if !e.expired(now) && !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward is like [LRU.All] but iterates from least to most
+// recently used.
+func (c *LRU[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if c.m == nil {
+			return
+		}
+		now := time.Now()
+		for ele := c.ll.Back(); ele != nil; ele = ele.Prev() {
+			e := ele.Value.(*entry[K, V])
+			if !e.expired(now) && !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of [LRU.All], from most to least
+// recently used.
+func (c *LRU[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range c.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Resize sets MaxEntries to size, evicting the oldest entries until
+// the cache fits, and returns how many were evicted.
+// A size of zero removes the limit.
+func (c *LRU[K, V]) Resize(size int) int {
+	c.MaxEntries = size
+	var n int
+	for size != 0 && c.Len() > size {
+		c.RemoveOldest()
+		n++
+	}
+	return n
+}
+
 // Remove removes the provided key from the cache.
 func (c *LRU[K, V]) Remove(key K) {
 	if c.m == nil {
 		return
 	}
 	if ele, hit := c.m[key]; hit {
-		c.removeElement(ele)
+		c.removeElement(ele, Removed)
 	}
 }
 
@@ -83,19 +262,40 @@ func (c *LRU[K, V]) RemoveOldest() {
 		return
 	}
 	if ele := c.ll.Back(); ele != nil {
-		c.removeElement(ele)
+		c.removeElement(ele, Capacity)
 	}
 }
 
-func (c *LRU[K, V]) removeElement(e *list.Element) {
+// RemoveExpired removes all expired items from the cache and
+// returns how many were removed.
+func (c *LRU[K, V]) RemoveExpired() int {
+	if c.m == nil {
+		return 0
+	}
+	var n int
+	now := time.Now()
+	for ele := c.ll.Back(); ele != nil; {
+		prev := ele.Prev()
+		if ele.Value.(*entry[K, V]).expired(now) {
+			c.removeElement(ele, Expired)
+			n++
+		}
+		ele = prev
+	}
+	return n
+}
+
+func (c *LRU[K, V]) removeElement(e *list.Element, reason Reason) {
 	kv := c.ll.Remove(e).(*entry[K, V])
 	delete(c.m, kv.key)
+	c.cost -= kv.cost
 	if c.OnEvicted != nil {
-		c.OnEvicted(kv.key, kv.value)
+		c.OnEvicted(kv.key, kv.value, reason)
 	}
 }
 
-// Len returns the number of items in the cache.
+// Len returns the number of items in the cache, including
+// expired items that have not yet been removed.
 func (c *LRU[K, V]) Len() int {
 	if c.m == nil {
 		return 0
@@ -108,9 +308,13 @@ func (c *LRU[K, V]) Clear() {
 	if c.OnEvicted != nil {
 		for _, e := range c.m {
 			kv := e.Value.(*entry[K, V])
-			c.OnEvicted(kv.key, kv.value)
+			c.OnEvicted(kv.key, kv.value, Cleared)
 		}
 	}
 	c.ll = nil
 	c.m = nil
+	c.cost = 0
 }
+
+// Cost returns the total cost of the items in the cache.
+func (c *LRU[K, V]) Cost() int64 { return c.cost }
@@ -7,7 +7,11 @@ package lru_test
 
 import (
 	"fmt"
+	"iter"
+	"slices"
 	"testing"
+	"testing/synctest"
+	"time"
 
 	. "go.adoublef.dev/container/lru"
 )
@@ -56,7 +60,10 @@ func TestLRU(t *testing.T) {
 	t.Run("Evict", func(t *testing.T) {
 		t.Parallel()
 		evictedKeys := make([]string, 0)
-		onEvictedFun := func(key string, value int) {
+		onEvictedFun := func(key string, value int, reason Reason) {
+			if reason != Capacity {
+				t.Errorf("got %v eviction reason; want %v", reason, Capacity)
+			}
 			evictedKeys = append(evictedKeys, key)
 		}
 
@@ -76,4 +83,135 @@ func TestLRU(t *testing.T) {
 			t.Fatalf("got %v in second evicted key; want %s", evictedKeys[1], "myKey1")
 		}
 	})
+
+	t.Run("TTL", func(t *testing.T) {
+		t.Parallel()
+		synctest.Test(t, func(t *testing.T) {
+			reasons := make(map[string]Reason)
+			lru := New[string, int](0)
+			lru.TTL = time.Minute
+			lru.OnEvicted = func(key string, value int, reason Reason) {
+				reasons[key] = reason
+			}
+			lru.Add("default", 1)
+			lru.AddWithTTL("short", 2, time.Second)
+			lru.AddWithTTL("forever", 3, 0)
+			lru.Add("swept", 4)
+
+			time.Sleep(time.Second)
+			if _, ok := lru.Get("short"); ok {
+				t.Fatal("TestTTL returned an expired entry")
+			}
+			if _, ok := lru.Get("default"); !ok {
+				t.Fatal("TestTTL returned no match before expiry")
+			}
+
+			time.Sleep(time.Minute)
+			if n := lru.RemoveExpired(); n != 2 {
+				t.Fatalf("got %d expired entries; want 2", n)
+			}
+			if _, ok := lru.Get("forever"); !ok {
+				t.Fatal("TestTTL expired an entry without a ttl")
+			}
+			for _, key := range []string{"short", "default", "swept"} {
+				if reasons[key] != Expired {
+					t.Fatalf("got %v eviction reason for %s; want %v", reasons[key], key, Expired)
+				}
+			}
+
+			lru.Remove("forever")
+			if reasons["forever"] != Removed {
+				t.Fatalf("got %v eviction reason; want %v", reasons["forever"], Removed)
+			}
+		})
+	})
+
+	t.Run("Peek", func(t *testing.T) {
+		t.Parallel()
+		lru := New[string, int](0)
+		lru.Add("a", 1)
+		lru.Add("b", 2)
+		if val, ok := lru.Peek("a"); !ok || val != 1 {
+			t.Fatalf("got %v, %t; want 1, true", val, ok)
+		}
+		if !lru.Contains("b") || lru.Contains("c") {
+			t.Fatal("TestPeek returned the wrong membership")
+		}
+		// neither call should have promoted "a"
+		if key, _, ok := lru.GetOldest(); !ok || key != "a" {
+			t.Fatalf("got %v in oldest key; want %s", key, "a")
+		}
+	})
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+		lru := New[string, int](0)
+		for _, key := range []string{"a", "b", "c"} {
+			lru.Add(key, 0)
+		}
+		lru.Get("a")
+		if got := keys(lru.All()); !slices.Equal(got, []string{"a", "c", "b"}) {
+			t.Fatalf("got %v from All; want %v", got, []string{"a", "c", "b"})
+		}
+		if got := keys(lru.Backward()); !slices.Equal(got, []string{"b", "c", "a"}) {
+			t.Fatalf("got %v from Backward; want %v", got, []string{"b", "c", "a"})
+		}
+		if got := slices.Collect(lru.Keys()); !slices.Equal(got, []string{"a", "c", "b"}) {
+			t.Fatalf("got %v from Keys; want %v", got, []string{"a", "c", "b"})
+		}
+	})
+
+	t.Run("Resize", func(t *testing.T) {
+		t.Parallel()
+		lru := New[string, int](0)
+		for i := range 10 {
+			lru.Add(fmt.Sprintf("myKey%d", i), i)
+		}
+		if n := lru.Resize(4); n != 6 {
+			t.Fatalf("got %d evicted keys; want 6", n)
+		}
+		if key, _, _ := lru.GetOldest(); key != "myKey6" {
+			t.Fatalf("got %v in oldest key; want %s", key, "myKey6")
+		}
+		lru.Add("myKey10", 10)
+		if lru.Len() != 4 {
+			t.Fatalf("got %d entries; want 4", lru.Len())
+		}
+	})
+
+	t.Run("Cost", func(t *testing.T) {
+		t.Parallel()
+		evictedKeys := make([]string, 0)
+		lru := New[string, string](0)
+		lru.MaxCost = 10
+		lru.CostFunc = func(key, value string) int64 { return int64(len(value)) }
+		lru.OnEvicted = func(key, value string, reason Reason) {
+			evictedKeys = append(evictedKeys, key)
+		}
+		lru.Add("a", "1234")
+		lru.Add("b", "1234")
+		lru.Add("c", "12")
+		if lru.Cost() != 10 || len(evictedKeys) != 0 {
+			t.Fatalf("got %d cost and %d evicted keys; want 10 and 0", lru.Cost(), len(evictedKeys))
+		}
+		lru.Add("a", "123456") // grows "a" and promotes it
+		if !slices.Equal(evictedKeys, []string{"b"}) {
+			t.Fatalf("got %v evicted keys; want %v", evictedKeys, []string{"b"})
+		}
+		if lru.Cost() != 8 {
+			t.Fatalf("got %d cost; want 8", lru.Cost())
+		}
+		lru.Remove("a")
+		if lru.Cost() != 2 {
+			t.Fatalf("got %d cost; want 2", lru.Cost())
+		}
+	})
+}
+
+func keys[K, V any](seq iter.Seq2[K, V]) []K {
+	var kk []K
+	for k := range seq {
+		kk = append(kk, k)
+	}
+	return kk
 }
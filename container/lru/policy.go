@@ -0,0 +1,237 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lru
+
+import (
+	"container/list"
+	"hash/maphash"
+	"iter"
+	"time"
+)
+
+// Cache is the behaviour shared by [LRU], [TwoQueue], [ARC] and [TinyLFU].
+// None of the implementations are safe for concurrent access.
+type Cache[K comparable, V any] interface {
+	Add(key K, value V)
+	AddWithTTL(key K, value V, ttl time.Duration)
+	Get(key K) (V, bool)
+	// Peek is like Get but does not count as a use of the entry.
+	Peek(key K) (V, bool)
+	Remove(key K)
+	// RemoveOldest removes the entry the policy would evict next.
+	RemoveOldest()
+	RemoveExpired() int
+	// Backward iterates over the unexpired entries from the one the
+	// policy would evict first to the one it would evict last.
+	Backward() iter.Seq2[K, V]
+	Len() int
+	Clear()
+}
+
+var (
+	_ Cache[string, int] = (*LRU[string, int])(nil)
+	_ Cache[string, int] = (*TwoQueue[string, int])(nil)
+	_ Cache[string, int] = (*ARC[string, int])(nil)
+	_ Cache[string, int] = (*TinyLFU[string, int])(nil)
+)
+
+// resident holds the entries of a policy split across ordered segments,
+// most recently used at the front of each.
+type resident[K comparable, V any] struct {
+	m    map[K]*list.Element
+	segs []*list.List
+}
+
+func newResident[K comparable, V any](n int) resident[K, V] {
+	r := resident[K, V]{m: make(map[K]*list.Element), segs: make([]*list.List, n)}
+	for i := range r.segs {
+		r.segs[i] = list.New()
+	}
+	return r
+}
+
+func (r *resident[K, V]) peek(key K) (V, bool) {
+	if _, e := r.get(key); e != nil && !e.expired(time.Now()) {
+		return e.value, true
+	}
+	return *new(V), false
+}
+
+func (r *resident[K, V]) get(key K) (*list.Element, *entry[K, V]) {
+	ele, ok := r.m[key]
+	if !ok {
+		return nil, nil
+	}
+	return ele, ele.Value.(*entry[K, V])
+}
+
+// push adds e to the front of seg.
+func (r *resident[K, V]) push(seg int, e *entry[K, V]) *list.Element {
+	e.seg = seg
+	ele := r.segs[seg].PushFront(e)
+	r.m[e.key] = ele
+	return ele
+}
+
+// move moves ele to the front of seg, returning the element now holding
+// its entry.
+func (r *resident[K, V]) move(ele *list.Element, seg int) *list.Element {
+	e := ele.Value.(*entry[K, V])
+	if e.seg == seg {
+		r.segs[seg].MoveToFront(ele)
+		return ele
+	}
+	r.segs[e.seg].Remove(ele)
+	return r.push(seg, e)
+}
+
+func (r *resident[K, V]) remove(ele *list.Element) *entry[K, V] {
+	e := ele.Value.(*entry[K, V])
+	r.segs[e.seg].Remove(ele)
+	delete(r.m, e.key)
+	return e
+}
+
+// back returns the least recently used element of seg.
+func (r *resident[K, V]) back(seg int) *list.Element { return r.segs[seg].Back() }
+
+func (r *resident[K, V]) len(seg int) int { return r.segs[seg].Len() }
+
+// backward iterates over the unexpired entries of each of segs in turn,
+// from least to most recently used.
+func (r *resident[K, V]) backward(segs ...int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for _, seg := range segs {
+			for ele := r.segs[seg].Back(); ele != nil; ele = ele.Prev() {
+				e := ele.Value.(*entry[K, V])
+				if !e.expired(now) && !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *resident[K, V]) expired(now time.Time) []*list.Element {
+	var ee []*list.Element
+	for _, ele := range r.m {
+		if ele.Value.(*entry[K, V]).expired(now) {
+			ee = append(ee, ele)
+		}
+	}
+	return ee
+}
+
+func (r *resident[K, V]) clear(onEvicted func(K, V, Reason)) {
+	if onEvicted != nil {
+		for _, ele := range r.m {
+			e := ele.Value.(*entry[K, V])
+			onEvicted(e.key, e.value, Cleared)
+		}
+	}
+	clear(r.m)
+	for _, l := range r.segs {
+		l.Init()
+	}
+}
+
+// ghost remembers the keys of recently evicted entries.
+type ghost[K comparable] struct {
+	ll *list.List
+	m  map[K]*list.Element
+}
+
+func newGhost[K comparable]() ghost[K] {
+	return ghost[K]{ll: list.New(), m: make(map[K]*list.Element)}
+}
+
+func (g *ghost[K]) push(key K) { g.m[key] = g.ll.PushFront(key) }
+
+func (g *ghost[K]) has(key K) bool { _, ok := g.m[key]; return ok }
+
+func (g *ghost[K]) remove(key K) {
+	if ele, ok := g.m[key]; ok {
+		g.ll.Remove(ele)
+		delete(g.m, key)
+	}
+}
+
+func (g *ghost[K]) removeOldest() {
+	if ele := g.ll.Back(); ele != nil {
+		delete(g.m, g.ll.Remove(ele).(K))
+	}
+}
+
+func (g *ghost[K]) len() int { return g.ll.Len() }
+
+func (g *ghost[K]) clear() {
+	clear(g.m)
+	g.ll.Init()
+}
+
+// sketch is a count-min sketch of 4-bit counters used to estimate how
+// often a key has been seen. Counters are halved once the number of
+// increments reaches the sample size so that old activity fades.
+type sketch[K comparable] struct {
+	seed    maphash.Seed
+	rows    [4][]uint8
+	mask    uint64
+	n, size int
+}
+
+func newSketch[K comparable](capacity int) sketch[K] {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	s := sketch[K]{seed: maphash.MakeSeed(), mask: uint64(width - 1), size: 10 * capacity}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch[K]) index(h uint64, i int) uint64 {
+	h += uint64(i) * (h>>32 | 1) // double hashing
+	return h & s.mask
+}
+
+func (s *sketch[K]) add(key K) {
+	h := maphash.Comparable(s.seed, key)
+	for i, row := range s.rows {
+		if j := s.index(h, i); row[j] < 15 {
+			row[j]++
+		}
+	}
+	if s.n++; s.n >= s.size {
+		s.reset()
+	}
+}
+
+func (s *sketch[K]) estimate(key K) uint8 {
+	h := maphash.Comparable(s.seed, key)
+	n := uint8(15)
+	for i, row := range s.rows {
+		n = min(n, row[s.index(h, i)])
+	}
+	return n
+}
+
+func (s *sketch[K]) reset() {
+	for _, row := range s.rows {
+		for j := range row {
+			row[j] >>= 1
+		}
+	}
+	s.n /= 2
+}
+
+func assert(exp bool, format string) {
+	if !exp {
+		panic(format)
+	}
+}
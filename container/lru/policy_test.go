@@ -0,0 +1,171 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lru_test
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "go.adoublef.dev/container/lru"
+)
+
+// policies returns a constructor for each eviction policy, wiring f as the
+// eviction callback.
+func policies() map[string]func(size int, f func(string, int, Reason)) Cache[string, int] {
+	return map[string]func(int, func(string, int, Reason)) Cache[string, int]{
+		"LRU": func(size int, f func(string, int, Reason)) Cache[string, int] {
+			c := New[string, int](size)
+			c.OnEvicted = f
+			return c
+		},
+		"2Q": func(size int, f func(string, int, Reason)) Cache[string, int] {
+			c := New2Q[string, int](size)
+			c.OnEvicted = f
+			return c
+		},
+		"ARC": func(size int, f func(string, int, Reason)) Cache[string, int] {
+			c := NewARC[string, int](size)
+			c.OnEvicted = f
+			return c
+		},
+		"TinyLFU": func(size int, f func(string, int, Reason)) Cache[string, int] {
+			c := NewTinyLFU[string, int](size)
+			c.OnEvicted = f
+			return c
+		},
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	for name, newCache := range policies() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Evict", func(t *testing.T) {
+				var evicted int
+				c := newCache(20, func(_ string, _ int, reason Reason) {
+					if reason != Capacity {
+						t.Errorf("got %v eviction reason; want %v", reason, Capacity)
+					}
+					evicted++
+				})
+				for i := range 100 {
+					c.Add("myKey"+strconv.Itoa(i), i)
+					if c.Len() > 20 {
+						t.Fatalf("got %d entries; want at most 20", c.Len())
+					}
+				}
+				if evicted+c.Len() != 100 {
+					t.Fatalf("got %d evicted and %d entries; want 100 in total", evicted, c.Len())
+				}
+			})
+
+			t.Run("Remove", func(t *testing.T) {
+				reasons := make(map[string]Reason)
+				c := newCache(20, func(key string, _ int, reason Reason) { reasons[key] = reason })
+				c.Add("myKey", 1234)
+				if val, ok := c.Get("myKey"); !ok || val != 1234 {
+					t.Fatalf("got %v, %t; want 1234, true", val, ok)
+				}
+				c.Remove("myKey")
+				if _, ok := c.Get("myKey"); ok {
+					t.Fatal("returned a removed entry")
+				}
+				if reasons["myKey"] != Removed {
+					t.Fatalf("got %v eviction reason; want %v", reasons["myKey"], Removed)
+				}
+
+				c.Add("other", 1)
+				c.Clear()
+				if c.Len() != 0 || reasons["other"] != Cleared {
+					t.Fatalf("got %d entries and %v eviction reason; want 0 and %v", c.Len(), reasons["other"], Cleared)
+				}
+			})
+
+			t.Run("Backward", func(t *testing.T) {
+				c := newCache(20, nil)
+				c.Add("a", 1)
+				c.Add("b", 3)
+				c.Get("a")
+				got := keys(c.Backward())
+				slices.Sort(got)
+				if !slices.Equal(got, []string{"a", "b"}) {
+					t.Fatalf("got %v from Backward; want %v", got, []string{"a", "b"})
+				}
+			})
+
+			t.Run("TTL", func(t *testing.T) {
+				synctest.Test(t, func(t *testing.T) {
+					reasons := make(map[string]Reason)
+					c := newCache(20, func(key string, _ int, reason Reason) { reasons[key] = reason })
+					c.AddWithTTL("short", 1, time.Second)
+					c.AddWithTTL("swept", 2, time.Second)
+					c.Add("forever", 3)
+
+					time.Sleep(time.Second)
+					if _, ok := c.Get("short"); ok {
+						t.Fatal("returned an expired entry")
+					}
+					if n := c.RemoveExpired(); n != 1 {
+						t.Fatalf("got %d expired entries; want 1", n)
+					}
+					if reasons["short"] != Expired || reasons["swept"] != Expired {
+						t.Fatalf("got %v and %v eviction reasons; want %v", reasons["short"], reasons["swept"], Expired)
+					}
+					if _, ok := c.Get("forever"); !ok {
+						t.Fatal("expired an entry without a ttl")
+					}
+				})
+			})
+		})
+	}
+
+	// A working set that is requested repeatedly should survive a scan
+	// over many keys that are each requested once.
+	t.Run("Scan", func(t *testing.T) {
+		t.Parallel()
+
+		const size, hot, scan = 100, 50, 1000
+		hits := make(map[string]int)
+		for name, newCache := range policies() {
+			c := newCache(size, nil)
+			access := func(key string) bool {
+				if _, ok := c.Get(key); ok {
+					return true
+				}
+				c.Add(key, 0)
+				return false
+			}
+			for range 5 {
+				for i := range hot {
+					access("hot" + strconv.Itoa(i))
+				}
+			}
+			for i := range scan {
+				access("scan" + strconv.Itoa(i))
+			}
+			for i := range hot {
+				if access("hot" + strconv.Itoa(i)) {
+					hits[name]++
+				}
+			}
+			t.Logf("%s: %d/%d hot keys survived the scan", name, hits[name], hot)
+		}
+		if hits["LRU"] != 0 {
+			t.Errorf("got %d LRU hits; want 0", hits["LRU"])
+		}
+		for _, name := range []string{"2Q", "ARC", "TinyLFU"} {
+			if hits[name] < hot/2 {
+				t.Errorf("got %d %s hits; want at least %d", hits[name], name, hot/2)
+			}
+		}
+	})
+}
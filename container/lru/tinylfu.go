@@ -0,0 +1,178 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lru
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+const (
+	tinyLFUWindow = iota
+	tinyLFUProbation
+	tinyLFUProtected
+)
+
+// TinyLFU is a W-TinyLFU cache. New entries enter a small LRU window and
+// must then win a frequency contest, estimated by a count-min sketch, against
+// the next victim of the main segmented LRU to stay in the cache.
+// It is not safe for concurrent access.
+//
+// See more: https://arxiv.org/abs/1512.00727
+type TinyLFU[K comparable, V any] struct {
+	// TTL is the time to live of entries added with [TinyLFU.Add].
+	// Zero means entries do not expire.
+	TTL time.Duration
+
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	OnEvicted func(key K, value V, reason Reason)
+
+	window    int // capacity of the window segment
+	protected int // capacity of the protected segment
+	main      int // capacity of the probation and protected segments
+	resident  resident[K, V]
+	sketch    sketch[K]
+}
+
+// NewTinyLFU creates a new [TinyLFU] holding at most size entries.
+func NewTinyLFU[K comparable, V any](size int) *TinyLFU[K, V] {
+	assert(size > 0, "size must be positive")
+
+	window := max(1, size/100)
+	return &TinyLFU[K, V]{
+		window:    window,
+		main:      size - window,
+		protected: (size - window) * 8 / 10,
+		resident:  newResident[K, V](3),
+		sketch:    newSketch[K](size),
+	}
+}
+
+// Add adds a value to the cache that expires after the default [TinyLFU.TTL].
+func (c *TinyLFU[K, V]) Add(key K, value V) {
+	c.AddWithTTL(key, value, c.TTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl.
+// If ttl is not positive the value does not expire.
+func (c *TinyLFU[K, V]) AddWithTTL(key K, value V, ttl time.Duration) {
+	c.sketch.add(key)
+	if ele, e := c.resident.get(key); e != nil {
+		e.value = value
+		e.setTTL(ttl)
+		c.promote(ele)
+		return
+	}
+	c.resident.push(tinyLFUWindow, newEntry(key, value, ttl))
+	if c.resident.len(tinyLFUWindow) <= c.window {
+		return
+	}
+	// the window is full so its oldest entry becomes a candidate for the
+	// main segments, where it has to beat the oldest probationary entry
+	candidate := c.resident.move(c.resident.back(tinyLFUWindow), tinyLFUProbation)
+	if c.resident.len(tinyLFUProbation)+c.resident.len(tinyLFUProtected) <= c.main {
+		return
+	}
+	victim := c.resident.back(tinyLFUProbation)
+	if victim != candidate {
+		ck := candidate.Value.(*entry[K, V]).key
+		vk := victim.Value.(*entry[K, V]).key
+		if c.sketch.estimate(ck) > c.sketch.estimate(vk) {
+			c.removeElement(victim, Capacity)
+			return
+		}
+	}
+	c.removeElement(candidate, Capacity)
+}
+
+// promote records a hit on ele, moving probationary entries into the
+// protected segment and demoting its oldest entry when it overflows.
+func (c *TinyLFU[K, V]) promote(ele *list.Element) {
+	switch ele.Value.(*entry[K, V]).seg {
+	case tinyLFUWindow:
+		c.resident.move(ele, tinyLFUWindow)
+	case tinyLFUProbation:
+		c.resident.move(ele, tinyLFUProtected)
+		if c.resident.len(tinyLFUProtected) > c.protected {
+			c.resident.move(c.resident.back(tinyLFUProtected), tinyLFUProbation)
+		}
+	case tinyLFUProtected:
+		c.resident.move(ele, tinyLFUProtected)
+	}
+}
+
+// Get looks up a key's value from the cache.
+// An expired entry is removed and reported as a miss.
+func (c *TinyLFU[K, V]) Get(key K) (V, bool) {
+	c.sketch.add(key)
+	ele, e := c.resident.get(key)
+	if e == nil {
+		return *new(V), false
+	}
+	if e.expired(time.Now()) {
+		c.removeElement(ele, Expired)
+		return *new(V), false
+	}
+	c.promote(ele)
+	return e.value, true
+}
+
+// Peek looks up a key's value from the cache without updating
+// its recency. An expired entry is reported as a miss but not removed.
+func (c *TinyLFU[K, V]) Peek(key K) (V, bool) { return c.resident.peek(key) }
+
+// Remove removes the provided key from the cache.
+func (c *TinyLFU[K, V]) Remove(key K) {
+	if ele, e := c.resident.get(key); e != nil {
+		c.removeElement(ele, Removed)
+	}
+}
+
+// RemoveOldest removes the entry the policy would evict next, which is the
+// oldest probationary entry if there is one.
+func (c *TinyLFU[K, V]) RemoveOldest() {
+	for _, seg := range []int{tinyLFUProbation, tinyLFUWindow, tinyLFUProtected} {
+		if ele := c.resident.back(seg); ele != nil {
+			c.removeElement(ele, Capacity)
+			return
+		}
+	}
+}
+
+// RemoveExpired removes all expired items from the cache and
+// returns how many were removed.
+func (c *TinyLFU[K, V]) RemoveExpired() int {
+	ee := c.resident.expired(time.Now())
+	for _, ele := range ee {
+		c.removeElement(ele, Expired)
+	}
+	return len(ee)
+}
+
+// Backward iterates over the unexpired entries of the probation, window
+// and protected segments, each from least to most recently used.
+func (c *TinyLFU[K, V]) Backward() iter.Seq2[K, V] {
+	return c.resident.backward(tinyLFUProbation, tinyLFUWindow, tinyLFUProtected)
+}
+
+// Len returns the number of items in the cache, including
+// expired items that have not yet been removed.
+func (c *TinyLFU[K, V]) Len() int { return len(c.resident.m) }
+
+// Clear purges all stored items from the cache.
+func (c *TinyLFU[K, V]) Clear() {
+	c.resident.clear(c.OnEvicted)
+}
+
+func (c *TinyLFU[K, V]) removeElement(ele *list.Element, reason Reason) *entry[K, V] {
+	e := c.resident.remove(ele)
+	if c.OnEvicted != nil {
+		c.OnEvicted(e.key, e.value, reason)
+	}
+	return e
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lru
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+const (
+	twoQueueIn = iota
+	twoQueueMain
+)
+
+// TwoQueue is a 2Q cache. New entries are admitted to a small FIFO queue
+// and only promoted to the main LRU queue when they are requested again,
+// either while queued or shortly after being evicted from it, so a single
+// scan cannot flush the working set.
+// It is not safe for concurrent access.
+//
+// See more: https://www.vldb.org/conf/1994/P439.PDF
+type TwoQueue[K comparable, V any] struct {
+	// TTL is the time to live of entries added with [TwoQueue.Add].
+	// Zero means entries do not expire.
+	TTL time.Duration
+
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from the cache.
+	OnEvicted func(key K, value V, reason Reason)
+
+	size     int
+	kin      int // capacity of the FIFO queue
+	kout     int // capacity of the ghost queue
+	resident resident[K, V]
+	out      ghost[K]
+}
+
+// New2Q creates a new [TwoQueue] holding at most size entries.
+func New2Q[K comparable, V any](size int) *TwoQueue[K, V] {
+	assert(size > 0, "size must be positive")
+
+	return &TwoQueue[K, V]{
+		size:     size,
+		kin:      max(1, size/4),
+		kout:     max(1, size/2),
+		resident: newResident[K, V](2),
+		out:      newGhost[K](),
+	}
+}
+
+// Add adds a value to the cache that expires after the default [TwoQueue.TTL].
+func (c *TwoQueue[K, V]) Add(key K, value V) {
+	c.AddWithTTL(key, value, c.TTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl.
+// If ttl is not positive the value does not expire.
+func (c *TwoQueue[K, V]) AddWithTTL(key K, value V, ttl time.Duration) {
+	if ele, e := c.resident.get(key); e != nil {
+		e.value = value
+		e.setTTL(ttl)
+		c.resident.move(ele, twoQueueMain)
+		return
+	}
+	seg := twoQueueIn
+	if c.out.has(key) {
+		c.out.remove(key)
+		seg = twoQueueMain
+	}
+	c.resident.push(seg, newEntry(key, value, ttl))
+	if len(c.resident.m) > c.size {
+		c.RemoveOldest()
+	}
+}
+
+// Get looks up a key's value from the cache.
+// An expired entry is removed and reported as a miss.
+func (c *TwoQueue[K, V]) Get(key K) (V, bool) {
+	ele, e := c.resident.get(key)
+	if e == nil {
+		return *new(V), false
+	}
+	if e.expired(time.Now()) {
+		c.removeElement(ele, Expired)
+		return *new(V), false
+	}
+	c.resident.move(ele, twoQueueMain)
+	return e.value, true
+}
+
+// Peek looks up a key's value from the cache without updating
+// its recency. An expired entry is reported as a miss but not removed.
+func (c *TwoQueue[K, V]) Peek(key K) (V, bool) { return c.resident.peek(key) }
+
+// Remove removes the provided key from the cache.
+func (c *TwoQueue[K, V]) Remove(key K) {
+	if ele, e := c.resident.get(key); e != nil {
+		c.removeElement(ele, Removed)
+	}
+}
+
+// RemoveOldest removes the entry the policy would evict next. The FIFO queue
+// is drained first while it is over capacity, its keys being remembered so
+// that a later request promotes them to the main queue.
+func (c *TwoQueue[K, V]) RemoveOldest() {
+	if c.resident.len(twoQueueIn) > c.kin || c.resident.len(twoQueueMain) == 0 {
+		ele := c.resident.back(twoQueueIn)
+		if ele == nil {
+			return
+		}
+		e := c.removeElement(ele, Capacity)
+		c.out.push(e.key)
+		if c.out.len() > c.kout {
+			c.out.removeOldest()
+		}
+		return
+	}
+	c.removeElement(c.resident.back(twoQueueMain), Capacity)
+}
+
+// RemoveExpired removes all expired items from the cache and
+// returns how many were removed.
+func (c *TwoQueue[K, V]) RemoveExpired() int {
+	ee := c.resident.expired(time.Now())
+	for _, ele := range ee {
+		c.removeElement(ele, Expired)
+	}
+	return len(ee)
+}
+
+// Backward iterates over the unexpired entries of the queue of entries
+// seen once and then the main queue, each from least to most recently used.
+func (c *TwoQueue[K, V]) Backward() iter.Seq2[K, V] {
+	return c.resident.backward(twoQueueIn, twoQueueMain)
+}
+
+// Len returns the number of items in the cache, including
+// expired items that have not yet been removed.
+func (c *TwoQueue[K, V]) Len() int { return len(c.resident.m) }
+
+// Clear purges all stored items from the cache.
+func (c *TwoQueue[K, V]) Clear() {
+	c.resident.clear(c.OnEvicted)
+	c.out.clear()
+}
+
+func (c *TwoQueue[K, V]) removeElement(ele *list.Element, reason Reason) *entry[K, V] {
+	e := c.resident.remove(ele)
+	if c.OnEvicted != nil {
+		c.OnEvicted(e.key, e.value, reason)
+	}
+	return e
+}
@@ -24,4 +24,18 @@ func CancelFunc[K comparable, V any](err error, rr []Request[K, V]) {
 	wg.Wait()
 }
 
+// FailPending replies to every request of rr that has not been answered,
+// or cancelled, with [ErrNoResult]. [Group] calls it once a batch has been
+// processed so that no caller waits for a result that will never come.
+func FailPending[K, V any](rr []Request[K, V]) {
+	for _, r := range rr {
+		if !r.replied() {
+			r.Reply(*new(V), ErrNoResult)
+		}
+	}
+}
+
 var ErrClosed = errors.New("use of closed connection")
+
+// ErrNoResult is returned for a request that a batch did not answer.
+var ErrNoResult = errors.New("batch returned no result")
@@ -10,122 +10,318 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.adoublef.dev/runtime/debug"
 )
 
 // Group is a generic type that manages batching of requests. It collects individual requests with the same input and output types, groups them together, and processes them in batches using a provided function.
 type Group[In, Out any] struct {
-	wg       sync.WaitGroup
-	init     sync.Once
-	requests chan Request[In, Out]
-	quit     chan struct{}
-	closed   atomic.Bool
+	// MaxBatchSize is the maximum number of requests in a batch.
+	// Zero means no limit.
+	MaxBatchSize int
+
+	// MaxLinger is how long a batch waits for more requests after its
+	// first arrives. Zero means a batch only takes the requests already queued.
+	MaxLinger time.Duration
+
+	// QueueSize is the number of requests that can wait to join a batch
+	// before Do blocks. Zero means 16.
+	QueueSize int
+
+	// Workers is the number of batches that can be processed at once.
+	// Zero means 1.
+	Workers int
+
+	wg        sync.WaitGroup
+	init      sync.Once
+	requests  chan Request[In, Out]
+	quit      chan struct{} // closed to fail queued requests
+	drain     chan struct{} // closed to process queued requests
+	quitOnce  sync.Once
+	drainOnce sync.Once
+	mu        sync.Mutex
+	closed    bool
+	senders   sync.WaitGroup // of requests being queued
 }
 
 // Do submits a request with the given key to be processed in a batch.
 // It returns the result of processing the request or an error.
 func (g *Group[In, Out]) Do(ctx context.Context, key In, f func(context.Context, []Request[In, Out])) (Out, error) {
-	if g.closed.Load() {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
 		return *new(Out), ErrClosed
 	}
 	g.init.Do(runLoop(g, f))
+	g.senders.Add(1)
+	g.mu.Unlock()
 
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
 	c := make(chan Out, 1)
+	rc := make(chan result[Out], 1)
 	r := Request[In, Out]{
 		Val:        key,
 		C:          c,
 		CancelFunc: cancel,
 		ctx:        ctx,
+		reply:      &reply[Out]{c: rc},
 	}
 
 	select {
 	case g.requests <- r: // was able to put it on the batch queue
+		g.senders.Done()
+	case <-g.quit:
+		g.senders.Done()
+		return *new(Out), ErrClosed
 	case <-ctx.Done():
+		g.senders.Done()
 		return *new(Out), context.Cause(ctx)
 	}
 	select {
 	case res := <-c:
 		return res, nil
+	case res := <-rc:
+		return res.val, res.err
 	case <-ctx.Done():
 		return *new(Out), context.Cause(ctx)
 	}
 }
 
-func runLoop[In, Out any](g *Group[In, Out], f func(context.Context, []Request[In, Out])) func() {
-	merge := func(ss []func() bool, ctx context.Context, cancel context.CancelFunc, n *atomic.Int64) []func() bool {
-		n.Add(1)
-		return append(ss, context.AfterFunc(ctx, func() {
-			if n.Add(-1) == 0 {
-				cancel()
-			}
-		}))
+// batch is a set of requests processed by one call to f. Its context is
+// cancelled once the contexts of all of its requests are done.
+type batch[In, Out any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	rr     []Request[In, Out]
+	ss     []func() bool
+	n      atomic.Int64 // of requests not yet done
+}
+
+func newBatch[In, Out any](size int) *batch[In, Out] {
+	b := &batch[In, Out]{rr: make([]Request[In, Out], 0, size), ss: make([]func() bool, 0, size)}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	return b
+}
+
+func (b *batch[In, Out]) add(r Request[In, Out]) {
+	b.n.Add(1)
+	b.rr = append(b.rr, r)
+	b.ss = append(b.ss, context.AfterFunc(r.ctx, func() {
+		if b.n.Add(-1) == 0 {
+			b.cancel()
+		}
+	}))
+}
+
+// fail replies to every request of b with err.
+func (b *batch[In, Out]) fail(err error) {
+	b.reply(*new(Out), err)
+	for _, stop := range b.ss {
+		stop()
 	}
+	b.cancel()
+}
 
+func (b *batch[In, Out]) run(f func(context.Context, []Request[In, Out])) {
+	debug.Printf("sync/batchque: %d = len(rr)", len(b.rr))
+	f(b.ctx, b.rr)
+	FailPending(b.rr)
+	for _, stop := range b.ss {
+		stop()
+	}
+	b.cancel()
+}
+
+func runLoop[In, Out any](g *Group[In, Out], f func(context.Context, []Request[In, Out])) func() {
 	return func() {
-		g.requests = make(chan Request[In, Out], 16) // backpressure?
+		size := g.QueueSize
+		if size == 0 {
+			size = 16
+		}
+		g.requests = make(chan Request[In, Out], size) // backpressure?
 		g.quit = make(chan struct{})
+		g.drain = make(chan struct{})
+		batches := make(chan *batch[In, Out])
 
-		g.wg.Add(1)
-		go func() {
-			defer g.wg.Done()
-			rr := make([]Request[In, Out], 0, 1<<10) // =1kb
-			ss := make([]func() bool, 0, 1<<10)      // =1kb
+		// send hands b to a worker, or fails it if the group is stopped
+		send := func(b *batch[In, Out]) {
+			select {
+			case batches <- b:
+			case <-g.quit:
+				b.fail(ErrClosed)
+			}
+		}
+		g.wg.Go(func() {
+			defer close(batches)
 			for {
-				ctx, cancel := context.WithCancel(context.Background())
-				var n atomic.Int64
 				select {
 				case r := <-g.requests:
-					rr = append(rr, r)
-					ss = merge(ss, r.ctx, cancel, &n)
-				EMPTY:
-					for {
-						select {
-						case r := <-g.requests:
-							rr = append(rr, r)
-							ss = merge(ss, r.ctx, cancel, &n)
-						default:
-							break EMPTY
-						}
+					b := g.newBatch()
+					b.add(r)
+					g.fill(b)
+					send(b)
+				case <-g.drain:
+					// every request has been queued so a last pass
+					// empties the queue
+					for len(g.requests) > 0 {
+						b := g.newBatch()
+						g.take(b)
+						send(b)
 					}
-					debug.Printf("sync/batchque: %d = len(rr)", len(rr))
-					f(ctx, rr)
-					for _, stop := range ss {
-						stop()
-					}
-					cancel()
-					rr = rr[:0]
-					ss = ss[:0]
+					return
 				case <-g.quit:
-					cancel()
+					g.senders.Wait()
+					for len(g.requests) > 0 {
+						b := g.newBatch()
+						g.take(b)
+						b.fail(ErrClosed)
+					}
 					return
 				}
 			}
-		}()
+		})
+
+		for range max(g.Workers, 1) {
+			g.wg.Go(func() {
+				for b := range batches {
+					select {
+					case <-g.quit:
+						b.fail(ErrClosed)
+					default:
+						b.run(f)
+					}
+				}
+			})
+		}
+	}
+}
+
+// newBatch returns an empty batch with room for MaxBatchSize requests,
+// or a single one when there is no limit and append grows it as needed.
+func (g *Group[In, Out]) newBatch() *batch[In, Out] {
+	size := 1
+	if g.MaxBatchSize > 0 {
+		size = min(g.MaxBatchSize, 1<<10)
+	}
+	return newBatch[In, Out](size)
+}
+
+// fill adds queued requests to b until it is full, waiting up to
+// MaxLinger for more to arrive.
+func (g *Group[In, Out]) fill(b *batch[In, Out]) {
+	if g.MaxLinger <= 0 {
+		g.take(b)
+		return
+	}
+	t := time.NewTimer(g.MaxLinger)
+	defer t.Stop()
+	for !g.full(b) {
+		select {
+		case r := <-g.requests:
+			b.add(r)
+		case <-t.C:
+			return
+		case <-g.drain:
+			return
+		case <-g.quit:
+			return
+		}
+	}
+}
+
+// take adds queued requests to b until it is full, without waiting.
+func (g *Group[In, Out]) take(b *batch[In, Out]) {
+	for !g.full(b) {
+		select {
+		case r := <-g.requests:
+			b.add(r)
+		default:
+			return
+		}
 	}
 }
 
+func (g *Group[In, Out]) full(b *batch[In, Out]) bool {
+	return g.MaxBatchSize > 0 && len(b.rr) >= g.MaxBatchSize
+}
+
 // Stop shuts down the Group's processing loop and waits for it to complete. Once closed, no new requests can be accepted.
+// Requests still queued are failed with [ErrClosed] while batches already being processed run to completion.
 // This method is safe to call multiple times.
 func (g *Group[In, Out]) Stop() {
-	if g.closed.CompareAndSwap(false, true) {
-		if g.quit != nil {
-			close(g.quit)
-		}
+	if g.close() {
+		g.quitOnce.Do(func() { close(g.quit) })
 		g.wg.Wait()
 	}
 }
 
+// Shutdown stops accepting new requests, which fail with [ErrClosed], and processes those
+// already queued in final batches. If ctx is done first, the requests not yet being processed
+// are failed with [ErrClosed] and ctx.Err() is returned without waiting for running batches.
+func (g *Group[In, Out]) Shutdown(ctx context.Context) error {
+	if !g.close() {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.senders.Wait()
+		g.drainOnce.Do(func() { close(g.drain) })
+		g.wg.Wait()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.quitOnce.Do(func() { close(g.quit) })
+		return ctx.Err()
+	}
+}
+
+// close rejects new requests and reports whether the processing loop was started.
+func (g *Group[In, Out]) close() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = true
+	return g.quit != nil
+}
+
 // Request represents a single operation within a batch. It contains the input value,
 // a channel to receive the result, and context management for cancellation.
 type Request[V, R any] struct {
 	Val        V        // Input
-	C          chan<- R // Result
+	C          chan<- R // Result, see also [Request.Reply]
 	CancelFunc context.CancelCauseFunc
 	ctx        context.Context
+	reply      *reply[R]
+}
+
+type result[R any] struct {
+	val R
+	err error
+}
+
+// reply holds the result of a request, set at most once.
+type reply[R any] struct {
+	c    chan result[R]
+	sent atomic.Bool
+}
+
+// Reply answers the request with a result and an error, which are returned
+// by [Group.Do]. Unlike CancelFunc it does not cancel the request's context.
+// Only the first reply is kept.
+func (r Request[V, R]) Reply(out R, err error) {
+	if r.reply != nil && r.reply.sent.CompareAndSwap(false, true) {
+		r.reply.c <- result[R]{out, err}
+	}
+}
+
+// replied reports whether the request has been answered or cancelled.
+func (r Request[V, R]) replied() bool {
+	return (r.reply != nil && r.reply.sent.Load()) || len(r.C) > 0 || r.Context().Err() != nil
 }
 
 // Context returns the context associated with this request.
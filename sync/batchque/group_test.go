@@ -11,7 +11,10 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/synctest"
+	"time"
 
 	. "go.adoublef.dev/sync/batchque"
 	"go.adoublef.dev/testing/is"
@@ -137,3 +140,201 @@ func TestGroup_Do(t *testing.T) {
 		}
 	})
 }
+
+func TestGroup_Options(t *testing.T) {
+	// echo replies to each request with the size of its batch
+	echo := func(_ context.Context, rr []Request[int, int]) {
+		for _, r := range rr {
+			r.C <- len(rr)
+		}
+	}
+
+	t.Run("MaxBatchSize", func(t *testing.T) {
+		g := Group[int, int]{MaxBatchSize: 3, MaxLinger: time.Second}
+		t.Cleanup(g.Stop)
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				n, err := g.Do(t.Context(), i, echo)
+				is.OK(t, err)
+				is.True(t, n >= 1 && n <= 3)
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("MaxLinger", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[int, int]{MaxLinger: time.Second}
+			defer g.Stop()
+
+			var wg sync.WaitGroup
+			sizes := make([]int, 5)
+			start := time.Now()
+			for i := range 5 {
+				wg.Go(func() {
+					var err error
+					sizes[i], err = g.Do(t.Context(), i, echo)
+					is.OK(t, err)
+				})
+				time.Sleep(100 * time.Millisecond)
+			}
+			wg.Wait()
+			is.Equal(t, time.Since(start), time.Second)
+			for _, n := range sizes {
+				is.Equal(t, n, 5) // one batch
+			}
+		})
+	})
+
+	t.Run("Workers", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[int, int]{Workers: 2, MaxBatchSize: 1}
+			defer g.Stop()
+
+			var running, peak atomic.Int64
+			var wg sync.WaitGroup
+			for i := range 6 {
+				wg.Go(func() {
+					_, err := g.Do(t.Context(), i, func(ctx context.Context, rr []Request[int, int]) {
+						n := running.Add(1)
+						for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+						}
+						time.Sleep(time.Second)
+						running.Add(-1)
+						echo(ctx, rr)
+					})
+					is.OK(t, err)
+				})
+			}
+			wg.Wait()
+			is.Equal(t, peak.Load(), int64(2))
+		})
+	})
+}
+
+func TestRequest_Reply(t *testing.T) {
+	var g Group[int, int]
+	t.Cleanup(g.Stop)
+
+	errOdd := errors.New("odd")
+	f := func(_ context.Context, rr []Request[int, int]) {
+		for _, r := range rr {
+			switch {
+			case r.Val < 0: // left unanswered
+			case r.Val%2 == 0:
+				r.Reply(r.Val*2, nil)
+				r.Reply(0, errOdd) // ignored
+			default:
+				r.Reply(0, errOdd)
+				is.OK(t, r.Context().Err()) // not cancelled
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			v, err := g.Do(t.Context(), i, f)
+			if i%2 == 0 {
+				is.OK(t, err)
+				is.Equal(t, v, i*2)
+			} else {
+				is.True(t, errors.Is(err, errOdd))
+			}
+		})
+	}
+	wg.Wait()
+
+	_, err := g.Do(t.Context(), -1, f)
+	is.True(t, errors.Is(err, ErrNoResult))
+}
+
+func TestGroup_Shutdown(t *testing.T) {
+	// slow replies to each request after a second
+	slow := func(_ context.Context, rr []Request[int, int]) {
+		time.Sleep(time.Second)
+		for _, r := range rr {
+			r.Reply(r.Val, nil)
+		}
+	}
+
+	t.Run("Drain", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[int, int]{MaxBatchSize: 1}
+
+			var wg sync.WaitGroup
+			for i := range 4 {
+				wg.Go(func() {
+					v, err := g.Do(t.Context(), i, slow)
+					is.OK(t, err)
+					is.Equal(t, v, i)
+				})
+			}
+			synctest.Wait()
+
+			is.OK(t, g.Shutdown(t.Context()))
+			wg.Wait()
+
+			_, err := g.Do(t.Context(), 0, slow)
+			is.True(t, errors.Is(err, ErrClosed))
+			is.OK(t, g.Shutdown(t.Context())) // no-op
+		})
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[int, int]{MaxBatchSize: 1}
+
+			var nok, nclosed atomic.Int64
+			var wg sync.WaitGroup
+			for i := range 4 {
+				wg.Go(func() {
+					_, err := g.Do(t.Context(), i, slow)
+					switch {
+					case err == nil:
+						nok.Add(1)
+					case errors.Is(err, ErrClosed):
+						nclosed.Add(1)
+					default:
+						t.Errorf("got %v; want nil or %v", err, ErrClosed)
+					}
+				})
+			}
+			synctest.Wait()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 1500*time.Millisecond)
+			defer cancel()
+			err := g.Shutdown(ctx)
+			is.True(t, errors.Is(err, context.DeadlineExceeded))
+			wg.Wait()
+			// the second batch was running when ctx was done
+			is.Equal(t, nok.Load(), int64(2))
+			is.Equal(t, nclosed.Load(), int64(2))
+			g.Stop()
+		})
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[int, int]{MaxBatchSize: 1}
+
+			var nclosed atomic.Int64
+			var wg sync.WaitGroup
+			for i := range 4 {
+				wg.Go(func() {
+					_, err := g.Do(t.Context(), i, slow)
+					if errors.Is(err, ErrClosed) {
+						nclosed.Add(1)
+					}
+				})
+			}
+			synctest.Wait()
+
+			g.Stop()
+			wg.Wait()
+			is.Equal(t, nclosed.Load(), int64(3))
+		})
+	})
+}
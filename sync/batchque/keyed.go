@@ -0,0 +1,116 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package batchque
+
+import (
+	"context"
+)
+
+// Keyed is a [Group] that partitions each batch by the key of its inputs,
+// such as a tenant or a table, calling the batch function once per partition.
+// Identical inputs within a partition are passed to the batch function once
+// and its reply is fanned out to every request for that input.
+//
+// The partitions of a batch are passed to the batch function one after
+// another, in the order they first appear, so that it runs no more often at
+// once than [Group.Workers] allows.
+type Keyed[K, In comparable, Out any] struct {
+	Group[In, Out]
+
+	// Key returns the partition of an input.
+	// Nil means every input belongs to the same partition.
+	Key func(In) K
+}
+
+// Do submits a request with the given input to be processed in a batch with
+// the other inputs of its partition. It returns the result of processing the
+// request or an error.
+func (g *Keyed[K, In, Out]) Do(ctx context.Context, in In, f func(context.Context, K, []Request[In, Out])) (Out, error) {
+	return g.Group.Do(ctx, in, func(ctx context.Context, rr []Request[In, Out]) {
+		kk, m := g.partition(rr)
+		for _, key := range kk {
+			dedup(ctx, key, m[key], f)
+		}
+	})
+}
+
+// partition groups rr by key, returning the keys in the order they first appear.
+func (g *Keyed[K, In, Out]) partition(rr []Request[In, Out]) ([]K, map[K][]Request[In, Out]) {
+	var kk []K
+	m := make(map[K][]Request[In, Out])
+	for _, r := range rr {
+		var key K
+		if g.Key != nil {
+			key = g.Key(r.Val)
+		}
+		if _, ok := m[key]; !ok {
+			kk = append(kk, key)
+		}
+		m[key] = append(m[key], r)
+	}
+	return kk, m
+}
+
+// dedup calls f with one request per distinct input of rr, then replies to
+// every request of rr with the result given for its input.
+func dedup[K, In comparable, Out any](ctx context.Context, key K, rr []Request[In, Out], f func(context.Context, K, []Request[In, Out])) {
+	var (
+		uu []Request[In, Out] // passed to f
+		cc []chan Out         // of each request of uu
+		bb []*batch[In, Out]  // requests for each input of uu
+	)
+	seen := make(map[In]int)
+	for _, r := range rr {
+		i, ok := seen[r.Val]
+		if !ok {
+			i = len(uu)
+			seen[r.Val] = i
+			b := newBatch[In, Out](1)
+			c := make(chan Out, 1)
+			uu = append(uu, Request[In, Out]{
+				Val:        r.Val,
+				C:          c,
+				CancelFunc: b.cancelFunc,
+				ctx:        b.ctx, // done once every request for the input is
+				reply:      &reply[Out]{c: make(chan result[Out], 1)},
+			})
+			cc, bb = append(cc, c), append(bb, b)
+		}
+		bb[i].add(r)
+	}
+
+	f(ctx, key, uu)
+
+	for i, u := range uu {
+		b := bb[i]
+		select {
+		case out := <-cc[i]:
+			b.reply(out, nil)
+		case res := <-u.reply.c:
+			b.reply(res.val, res.err)
+		default:
+			// left for FailPending
+		}
+		for _, stop := range b.ss {
+			stop()
+		}
+		b.cancel()
+	}
+}
+
+// cancelFunc cancels every request of b.
+func (b *batch[In, Out]) cancelFunc(err error) {
+	for _, r := range b.rr {
+		r.CancelFunc(err)
+	}
+}
+
+// reply answers every request of b with a result and an error.
+func (b *batch[In, Out]) reply(out Out, err error) {
+	for _, r := range b.rr {
+		r.Reply(out, err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package batchque_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "go.adoublef.dev/sync/batchque"
+	"go.adoublef.dev/testing/is"
+)
+
+func TestKeyed_Do(t *testing.T) {
+	type id struct {
+		Tenant string
+		N      int
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Keyed[string, id, int]{Key: func(v id) string { return v.Tenant }}
+			g.MaxLinger = time.Second
+			defer g.Stop()
+
+			// f is not safe for concurrent use, as with Group it is
+			// called for one partition at a time
+			var running atomic.Int64
+			calls := make(map[string][]int)
+			f := func(_ context.Context, tenant string, rr []Request[id, int]) {
+				is.Equal(t, running.Add(1), int64(1))
+				defer running.Add(-1)
+				time.Sleep(time.Millisecond)
+				for _, r := range rr {
+					is.Equal(t, r.Val.Tenant, tenant)
+					calls[tenant] = append(calls[tenant], r.Val.N)
+					r.C <- r.Val.N * 10
+				}
+			}
+
+			var wg sync.WaitGroup
+			for _, tenant := range []string{"a", "b"} {
+				for n := range 6 {
+					wg.Go(func() {
+						v, err := g.Do(t.Context(), id{tenant, n % 3}, f)
+						is.OK(t, err)
+						is.Equal(t, v, n%3*10)
+					})
+				}
+			}
+			wg.Wait()
+
+			is.Equal(t, len(calls), 2)
+			for _, nn := range calls {
+				is.Equal(t, len(nn), 3) // one per distinct input
+			}
+		})
+	})
+
+	t.Run("Reply", func(t *testing.T) {
+		var g Keyed[string, id, int]
+		t.Cleanup(g.Stop)
+
+		errOdd := errors.New("odd")
+		f := func(_ context.Context, _ string, rr []Request[id, int]) {
+			for _, r := range rr {
+				switch {
+				case r.Val.N < 0: // left unanswered
+				case r.Val.N%2 == 0:
+					r.Reply(r.Val.N, nil)
+				default:
+					r.Reply(0, errOdd)
+				}
+			}
+		}
+
+		var wg sync.WaitGroup
+		for n := range 10 {
+			wg.Go(func() {
+				v, err := g.Do(t.Context(), id{"a", n / 2}, f)
+				if n/2%2 == 0 {
+					is.OK(t, err)
+					is.Equal(t, v, n/2)
+				} else {
+					is.True(t, errors.Is(err, errOdd))
+				}
+			})
+		}
+		wg.Wait()
+
+		_, err := g.Do(t.Context(), id{"a", -1}, f)
+		is.True(t, errors.Is(err, ErrNoResult))
+	})
+
+	t.Run("CancelFunc", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var g Keyed[string, id, int]
+			g.MaxLinger = time.Second
+			defer g.Stop()
+
+			errCancel := errors.New("cancelled")
+			f := func(_ context.Context, _ string, rr []Request[id, int]) {
+				CancelFunc(errCancel, rr)
+			}
+
+			var wg sync.WaitGroup
+			for range 3 {
+				wg.Go(func() {
+					_, err := g.Do(t.Context(), id{"a", 1}, f)
+					is.True(t, errors.Is(err, errCancel))
+				})
+			}
+			wg.Wait()
+		})
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec converts values to and from the bytes stored in a cache.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(p []byte, v any) error
+}
+
+var (
+	// Msgpack encodes values with MessagePack. It is the default codec.
+	Msgpack Codec = msgpackCodec{}
+	// JSON encodes values with [encoding/json].
+	JSON Codec = jsonCodec{}
+	// Gob encodes values with [encoding/gob].
+	Gob Codec = gobCodec{}
+	// Pointer stores values as they are, without serializing or copying
+	// them. It must only be used for values that are never mutated once
+	// added or retrieved, and sizes only count the value's own memory
+	// rather than anything it points to.
+	Pointer Codec = pointerCodec{}
+)
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error)   { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(p []byte, v any) error { return msgpack.Unmarshal(p, v) }
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)   { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(p []byte, v any) error { return json.Unmarshal(p, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(p []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(p)).Decode(v)
+}
+
+type pointerCodec struct{}
+
+var errPointerCodec = errors.New("cache: the Pointer codec does not serialize values")
+
+func (pointerCodec) Marshal(v any) ([]byte, error)   { return nil, errPointerCodec }
+func (pointerCodec) Unmarshal(p []byte, v any) error { return errPointerCodec }
+
+// item is a cached value, held either as the bytes produced by a [Codec]
+// or as is when using [Pointer].
+type item[V any] struct {
+	p       []byte
+	v       V
+	ptr     bool
+	expires time.Time // zero means never, kept for snapshots
+}
+
+// size of the key and serialized value
+func size[K Key, V any](key K, it item[V]) int64 {
+	if it.ptr {
+		return int64(len(key)) + int64(unsafe.Sizeof(it.v))
+	}
+	return int64(len(key)) + int64(len(it.p))
+}
+
+func (c *LRU[K, V]) codec() Codec {
+	if c.Codec == nil {
+		return Msgpack
+	}
+	return c.Codec
+}
+
+func (c *LRU[K, V]) encode(value V) (item[V], error) {
+	codec := c.codec()
+	if _, ok := codec.(pointerCodec); ok {
+		return item[V]{v: value, ptr: true}, nil
+	}
+	p, err := codec.Marshal(value)
+	if err != nil {
+		return item[V]{}, fmt.Errorf("cannot convert struct to bytes slice: %w", err)
+	}
+	return item[V]{p: p}, nil
+}
+
+func (c *LRU[K, V]) decode(it item[V]) (v V, err error) {
+	if it.ptr {
+		return it.v, nil
+	}
+	err = c.codec().Unmarshal(it.p, &v)
+	if err != nil {
+		return v, fmt.Errorf("cannot convert bytes slice to struct: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "go.adoublef.dev/sync/cache"
+)
+
+func Test_Codec(t *testing.T) {
+	type simple struct {
+		I int64
+		S string
+	}
+
+	for name, codec := range map[string]Codec{
+		"Msgpack": Msgpack,
+		"JSON":    JSON,
+		"Gob":     Gob,
+		"Pointer": Pointer,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := LRU[string, simple]{Codec: codec}
+			if err := c.Add("1", simple{23, "Hello"}); err != nil {
+				t.Fatal(err)
+			}
+			v, ok := c.Get("1")
+			if !ok || v != (simple{23, "Hello"}) {
+				t.Fatalf("got %v, %t; want %v, true", v, ok, simple{23, "Hello"})
+			}
+			if c.Bytes() <= 1 {
+				t.Fatalf("got %d bytes; want more than the key", c.Bytes())
+			}
+		})
+	}
+
+	t.Run("Pointer", func(t *testing.T) {
+		c := LRU[string, *int]{Codec: Pointer}
+		p := new(int)
+		_ = c.Add("1", p)
+		if v, _ := c.Get("1"); v != p {
+			t.Fatal("returned a copy of the value")
+		}
+	})
+
+	t.Run("DecodeError", func(t *testing.T) {
+		c := LRU[string, int]{Codec: badCodec{}}
+		if err := c.Add("1", 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := c.Get("1"); ok {
+			t.Fatal("returned a value that could not be decoded")
+		}
+		// a read-through cache treats it as a miss
+		v, err := c.GetOrLoad(context.Background(), "1", func(context.Context, string) (int, error) {
+			return 2, nil
+		})
+		if err != nil || v != 2 {
+			t.Fatalf("got %d, %v; want 2, nil", v, err)
+		}
+		if s := c.Stats(); s.Decodes != 2 || s.Items != 1 {
+			t.Fatalf("got %d decode errors and %d items; want 2 and 1", s.Decodes, s.Items)
+		}
+	})
+}
+
+var errDecode = errors.New("cannot decode")
+
+// badCodec encodes values it cannot decode.
+type badCodec struct{}
+
+func (badCodec) Marshal(v any) ([]byte, error)   { return []byte{0}, nil }
+func (badCodec) Unmarshal(p []byte, v any) error { return errDecode }
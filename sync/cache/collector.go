@@ -0,0 +1,81 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	descGets      = newDesc("gets_total", "Number of cache lookups.")
+	descHits      = newDesc("hits_total", "Number of cache lookups that found a value.")
+	descMisses    = newDesc("misses_total", "Number of cache lookups that did not find a value.")
+	descEvictions = newDesc("evictions_total", "Number of entries evicted by capacity or expiry.")
+	descDecodes   = newDesc("decode_errors_total", "Number of values that could not be decoded.")
+	descBytes     = newDesc("bytes", "Size of all keys and serialized values in bytes.")
+	descItems     = newDesc("items", "Number of entries in the cache.")
+	descHitRatio  = newDesc("hit_ratio", "Fraction of cache lookups that found a value.")
+)
+
+func newDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("", "cache", name), help, []string{"cache"}, nil)
+}
+
+// Collector is a [prometheus.Collector] exporting the [Stats] of caches,
+// each labelled by the name it was added with. The zero value is ready to use.
+type Collector struct {
+	mu     sync.Mutex
+	caches map[string]interface{ Stats() Stats }
+}
+
+// Add exports the statistics of c under name, replacing any cache
+// previously added with the same name. c is usually a [LRU] or [Sharded].
+func (col *Collector) Add(name string, c interface{ Stats() Stats }) {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	if col.caches == nil {
+		col.caches = make(map[string]interface{ Stats() Stats })
+	}
+	col.caches[name] = c
+}
+
+// Remove stops exporting the statistics of the cache added under name.
+func (col *Collector) Remove(name string) {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	delete(col.caches, name)
+}
+
+// Describe implements [prometheus.Collector].
+func (col *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{descGets, descHits, descMisses, descEvictions, descDecodes, descBytes, descItems, descHitRatio} {
+		ch <- d
+	}
+}
+
+// Collect implements [prometheus.Collector].
+func (col *Collector) Collect(ch chan<- prometheus.Metric) {
+	col.mu.Lock()
+	names := slices.Sorted(maps.Keys(col.caches))
+	caches := maps.Clone(col.caches)
+	col.mu.Unlock()
+
+	for _, name := range names {
+		s := caches[name].Stats()
+		ch <- prometheus.MustNewConstMetric(descGets, prometheus.CounterValue, float64(s.Gets), name)
+		ch <- prometheus.MustNewConstMetric(descHits, prometheus.CounterValue, float64(s.Hits), name)
+		ch <- prometheus.MustNewConstMetric(descMisses, prometheus.CounterValue, float64(s.Misses), name)
+		ch <- prometheus.MustNewConstMetric(descEvictions, prometheus.CounterValue, float64(s.Evictions), name)
+		ch <- prometheus.MustNewConstMetric(descDecodes, prometheus.CounterValue, float64(s.Decodes), name)
+		ch <- prometheus.MustNewConstMetric(descBytes, prometheus.GaugeValue, float64(s.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(descItems, prometheus.GaugeValue, float64(s.Items), name)
+		ch <- prometheus.MustNewConstMetric(descHitRatio, prometheus.GaugeValue, s.HitRatio(), name)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package invalidate fans out cache invalidations between instances over
+// NATS, so that removing a key on one instance removes it on all of them.
+package invalidate
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.adoublef.dev/runtime/debug"
+	"go.adoublef.dev/sync/cache"
+)
+
+// Cache is the part of a cache that can be invalidated.
+// It is implemented by [cache.LRU] and [cache.Sharded].
+type Cache[K cache.Key] interface {
+	Remove(key K)
+	RemovePrefix(prefix string) int
+}
+
+var (
+	_ Cache[string] = (*cache.LRU[string, int])(nil)
+	_ Cache[string] = (*cache.Sharded[string, int])(nil)
+)
+
+// headerOrigin identifies the bus that published a message.
+const headerOrigin = "Cache-Origin"
+
+// message is an invalidation sent between buses.
+type message[K cache.Key] struct {
+	Keys     []K      `msgpack:"k,omitempty"`
+	Prefixes []string `msgpack:"p,omitempty"`
+}
+
+// Bus publishes the invalidations of a cache on a NATS subject and applies
+// those published by every other bus subscribed to the same subject.
+type Bus[K cache.Key] struct {
+	nc      *nats.Conn
+	subject string
+	cache   Cache[K]
+	origin  string
+	sub     *nats.Subscription
+}
+
+// New creates a new [Bus] invalidating c, subscribing to subject on nc.
+func New[K cache.Key](nc *nats.Conn, subject string, c Cache[K]) (*Bus[K], error) {
+	assert(nc != nil, "connection cannot be nil")
+	assert(c != nil, "cache cannot be nil")
+
+	b := &Bus[K]{nc: nc, subject: subject, cache: c, origin: nuid.Next()}
+	sub, err := nc.Subscribe(subject, b.apply)
+	if err != nil {
+		return nil, fmt.Errorf("cannot subscribe to %q: %w", subject, err)
+	}
+	b.sub = sub
+	return b, nil
+}
+
+// Remove removes keys from the local cache and publishes their removal.
+func (b *Bus[K]) Remove(keys ...K) error {
+	for _, key := range keys {
+		b.cache.Remove(key)
+	}
+	return b.publish(message[K]{Keys: keys})
+}
+
+// RemovePrefix removes the keys starting with any of prefixes from the
+// local cache and publishes their removal.
+func (b *Bus[K]) RemovePrefix(prefixes ...string) error {
+	for _, prefix := range prefixes {
+		b.cache.RemovePrefix(prefix)
+	}
+	return b.publish(message[K]{Prefixes: prefixes})
+}
+
+func (b *Bus[K]) publish(m message[K]) error {
+	p, err := msgpack.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("cannot convert struct to bytes slice: %w", err)
+	}
+	msg := &nats.Msg{Subject: b.subject, Header: nats.Header{}, Data: p}
+	msg.Header.Set(headerOrigin, b.origin)
+	err = b.nc.PublishMsg(msg)
+	if err != nil {
+		return fmt.Errorf("cannot publish to %q: %w", b.subject, err)
+	}
+	return nil
+}
+
+// apply removes the keys of a message published by another bus.
+func (b *Bus[K]) apply(msg *nats.Msg) {
+	if msg.Header.Get(headerOrigin) == b.origin {
+		return
+	}
+	var m message[K]
+	if err := msgpack.Unmarshal(msg.Data, &m); err != nil {
+		debug.Printf("sync/cache/invalidate: %v := msgpack.Unmarshal(msg.Data, &m)", err)
+		return
+	}
+	for _, key := range m.Keys {
+		b.cache.Remove(key)
+	}
+	for _, prefix := range m.Prefixes {
+		b.cache.RemovePrefix(prefix)
+	}
+}
+
+// Close stops applying invalidations published by other buses.
+// It does not close the connection.
+func (b *Bus[K]) Close() error {
+	return b.sub.Unsubscribe()
+}
+
+func assert(exp bool, format string) {
+	if !exp {
+		panic(format)
+	}
+}
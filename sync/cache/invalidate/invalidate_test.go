@@ -0,0 +1,126 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package invalidate_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"go.adoublef.dev/sync/cache"
+	. "go.adoublef.dev/sync/cache/invalidate"
+	"go.adoublef.dev/testing/wait"
+)
+
+func TestBus(t *testing.T) {
+	url := newServer(t)
+
+	caches := make([]*cache.LRU[string, int], 3)
+	buses := make([]*Bus[string], 3)
+	conns := make([]*nats.Conn, 3)
+	for i := range caches {
+		nc, err := nats.Connect(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(nc.Close)
+		conns[i] = nc
+		caches[i] = new(cache.LRU[string, int])
+		buses[i], err = New(nc, "cache.invalidate", caches[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := nc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add := func(keys ...string) {
+		for _, c := range caches {
+			for _, key := range keys {
+				_ = c.Add(key, 1)
+			}
+		}
+	}
+	// removed waits for every cache, or only cc if given, to have dropped key
+	removed := func(t *testing.T, key string, cc ...*cache.LRU[string, int]) {
+		t.Helper()
+		if cc == nil {
+			cc = caches
+		}
+		err := wait.ForFunc(t.Context(), 5*time.Second, func() error {
+			for _, c := range cc {
+				if _, ok := c.Get(key); ok {
+					return errors.New("key is still cached")
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", key, err)
+		}
+	}
+
+	t.Run("Remove", func(t *testing.T) {
+		add("a", "b")
+		if err := buses[0].Remove("a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := caches[0].Get("a"); ok {
+			t.Fatal("did not remove the key locally")
+		}
+		removed(t, "a")
+		for _, c := range caches {
+			if _, ok := c.Get("b"); !ok {
+				t.Fatal("removed another key")
+			}
+		}
+	})
+
+	t.Run("RemovePrefix", func(t *testing.T) {
+		add("user:1", "user:2", "team:1")
+		if err := buses[1].RemovePrefix("user:"); err != nil {
+			t.Fatal(err)
+		}
+		removed(t, "user:1")
+		removed(t, "user:2")
+		for _, c := range caches {
+			if _, ok := c.Get("team:1"); !ok {
+				t.Fatal("removed a key without the prefix")
+			}
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		if err := buses[2].Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := conns[2].Flush(); err != nil {
+			t.Fatal(err)
+		}
+		add("c")
+		_ = buses[0].Remove("c")
+		removed(t, "c", caches[:2]...)
+		if _, ok := caches[2].Get("c"); !ok {
+			t.Fatal("closed bus applied an invalidation")
+		}
+	})
+}
+
+func newServer(t *testing.T) string {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if err := wait.ForNATS(t.Context(), ns, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return ns.ClientURL()
+}
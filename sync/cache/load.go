@@ -0,0 +1,129 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go.adoublef.dev/container/lru"
+)
+
+// LoadFunc loads the value for a key that is missing from the cache.
+type LoadFunc[K Key, V any] func(ctx context.Context, key K) (V, error)
+
+// call is a load in flight. Callers waiting on the same key share it.
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// GetOrLoad retrieves a value from the cache, calling load on a miss and
+// adding its result. Concurrent callers for the same key share a single
+// call to load, which runs on its own goroutine so that a caller giving up
+// does not fail the others.
+//
+// A cached value that cannot be decoded, say after a change of Codec, is
+// removed and loaded again.
+//
+// See [LRU.RefreshAfter] and [LRU.ErrorTTL] for serving stale values and
+// remembering failed loads.
+func (c *LRU[K, V]) GetOrLoad(ctx context.Context, key K, load LoadFunc[K, V]) (V, error) {
+	c.mu.Lock()
+	v, ok, err := c.getLocked(key)
+	if err != nil {
+		c.lru.Remove(key)
+	}
+	if ok {
+		due, ok := c.refresh[key]
+		if stale := ok && !time.Now().Before(due); stale {
+			c.loadLocked(context.WithoutCancel(ctx), key, load)
+		}
+		c.mu.Unlock()
+		return v, nil
+	}
+	if c.errs != nil {
+		if err, ok := c.errs.Get(key); ok {
+			c.mu.Unlock()
+			return *new(V), err
+		}
+	}
+	r := c.loadLocked(context.WithoutCancel(ctx), key, load)
+	c.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.val, r.err
+	case <-ctx.Done():
+		return *new(V), context.Cause(ctx)
+	}
+}
+
+// loadLocked starts a load of key unless one is already in flight.
+func (c *LRU[K, V]) loadLocked(ctx context.Context, key K, load LoadFunc[K, V]) *call[V] {
+	if r, ok := c.calls[key]; ok {
+		return r
+	}
+	if c.calls == nil {
+		c.calls = make(map[K]*call[V])
+	}
+	r := &call[V]{done: make(chan struct{})}
+	c.calls[key] = r
+
+	go func() {
+		defer close(r.done)
+		r.val, r.err = load(ctx, key)
+		var it item[V]
+		if r.err == nil {
+			it, r.err = c.encode(r.val)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.calls[key] != r {
+			return // the key was written or removed since the load began
+		}
+		delete(c.calls, key)
+		if r.err != nil {
+			c.failLocked(key, r.err)
+			return
+		}
+		c.addLocked(key, it, c.TTL)
+		if c.RefreshAfter > 0 {
+			if c.refresh == nil {
+				c.refresh = make(map[K]time.Time)
+			}
+			c.refresh[key] = time.Now().Add(c.RefreshAfter)
+		}
+	}()
+	return r
+}
+
+// forgetLocked drops the refresh deadline of key and detaches any load of
+// it in flight, so that an older value is not written back once it returns.
+// Callers already waiting on the load still receive its result.
+func (c *LRU[K, V]) forgetLocked(key K) {
+	delete(c.refresh, key)
+	delete(c.calls, key)
+}
+
+// failLocked remembers that loading key failed, unless a stale value is
+// still being served for it.
+func (c *LRU[K, V]) failLocked(key K, err error) {
+	if c.ErrorTTL <= 0 {
+		return
+	}
+	if c.lru != nil {
+		if _, ok := c.lru.Peek(key); ok {
+			return
+		}
+	}
+	if c.errs == nil {
+		c.errs = lru.New[K, error](c.MaxEntries)
+	}
+	c.errs.AddWithTTL(key, err, c.ErrorTTL)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "go.adoublef.dev/sync/cache"
+	"go.adoublef.dev/testing/is"
+)
+
+func Test_Cache_GetOrLoad(t *testing.T) {
+	t.Run("Coalesce", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var c LRU[string, string]
+			var n atomic.Int64
+			load := func(ctx context.Context, key string) (string, error) {
+				n.Add(1)
+				time.Sleep(time.Second)
+				return "Hello " + key, nil
+			}
+
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Go(func() {
+					v, err := c.GetOrLoad(t.Context(), "World", load)
+					is.OK(t, err)
+					is.Equal(t, v, "Hello World")
+				})
+			}
+			wg.Wait()
+			is.Equal(t, n.Load(), int64(1))
+
+			v, ok := c.Get("World")
+			is.True(t, ok)
+			is.Equal(t, v, "Hello World")
+		})
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var c LRU[string, string]
+			load := func(ctx context.Context, key string) (string, error) {
+				time.Sleep(time.Minute)
+				return "Hello", ctx.Err()
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+			defer cancel()
+			_, err := c.GetOrLoad(ctx, "1", load)
+			is.True(t, errors.Is(err, context.DeadlineExceeded))
+
+			// the load carries on for the next caller
+			v, err := c.GetOrLoad(t.Context(), "1", load)
+			is.OK(t, err)
+			is.Equal(t, v, "Hello")
+		})
+	})
+
+	t.Run("ErrorTTL", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			c := LRU[string, string]{ErrorTTL: time.Minute}
+			var n atomic.Int64
+			errNotFound := errors.New("not found")
+			load := func(ctx context.Context, key string) (string, error) {
+				if n.Add(1) == 1 {
+					return "", errNotFound
+				}
+				return "Hello", nil
+			}
+
+			_, err := c.GetOrLoad(t.Context(), "1", load)
+			is.True(t, errors.Is(err, errNotFound))
+			_, err = c.GetOrLoad(t.Context(), "1", load)
+			is.True(t, errors.Is(err, errNotFound))
+			is.Equal(t, n.Load(), int64(1))
+
+			time.Sleep(time.Minute)
+			v, err := c.GetOrLoad(t.Context(), "1", load)
+			is.OK(t, err)
+			is.Equal(t, v, "Hello")
+		})
+	})
+
+	t.Run("RefreshAfter", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			c := LRU[string, int64]{RefreshAfter: time.Minute}
+			var n atomic.Int64
+			load := func(ctx context.Context, key string) (int64, error) {
+				return n.Add(1), nil
+			}
+
+			v, err := c.GetOrLoad(t.Context(), "1", load)
+			is.OK(t, err)
+			is.Equal(t, v, int64(1))
+
+			time.Sleep(time.Minute)
+			v, err = c.GetOrLoad(t.Context(), "1", load)
+			is.OK(t, err)
+			is.Equal(t, v, int64(1)) // stale
+
+			synctest.Wait()
+			v, err = c.GetOrLoad(t.Context(), "1", load)
+			is.OK(t, err)
+			is.Equal(t, v, int64(2))
+		})
+	})
+	t.Run("Remove", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var c LRU[string, string]
+			release := make(chan struct{})
+			load := func(ctx context.Context, key string) (string, error) {
+				<-release
+				return "stale", nil
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				v, err := c.GetOrLoad(t.Context(), "k", load)
+				is.OK(t, err)
+				is.Equal(t, v, "stale") // the caller still gets its load
+			}()
+			synctest.Wait()
+
+			c.Remove("k")
+			close(release)
+			<-done
+			synctest.Wait()
+
+			_, ok := c.Get("k")
+			is.True(t, !ok)
+
+			// a write during a load is not overwritten either
+			release = make(chan struct{})
+			go c.GetOrLoad(t.Context(), "k", load)
+			synctest.Wait()
+			is.OK(t, c.Add("k", "fresh"))
+			close(release)
+			synctest.Wait()
+
+			v, ok := c.Get("k")
+			is.True(t, ok)
+			is.Equal(t, v, "fresh")
+		})
+	})
+}
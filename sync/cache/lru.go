@@ -4,16 +4,20 @@
 // license that can be found in the LICENSE file.
 
 // Package cache provides a generic in-memory LRU (Least Recently Used) cache implementation.
-// The cache stores any Go value by serializing it to bytes using MessagePack encoding,
-// making it suitable for a wide variety of data types.
+// The cache stores any Go value by serializing it to bytes using a [Codec], MessagePack
+// by default, making it suitable for a wide variety of data types.
 package cache
 
 import (
-	"fmt"
+	"context"
+	"iter"
+	"maps"
+	"strings"
 	"sync"
+	"time"
 
-	"github.com/vmihailenco/msgpack/v5"
 	"go.adoublef.dev/container/lru"
+	"go.adoublef.dev/os/du"
 )
 
 type Key interface {
@@ -22,63 +26,234 @@ type Key interface {
 }
 
 // LRU is a generic LRU cache that can store any value type.
+// Despite its name the eviction policy can be changed with Policy.
 type LRU[K Key, V any] struct {
+	// MaxEntries is the maximum number of cache entries before
+	// an item is evicted. Zero means no limit.
+	MaxEntries int
+
+	// MaxBytes is the maximum size of all keys and serialized values
+	// before the oldest items are evicted. Zero means no limit.
+	MaxBytes du.Size
+
+	// Policy is the eviction policy used once MaxEntries is reached.
+	// It must be set before the first call to Add.
+	Policy Policy
+
+	// Codec converts values to and from the bytes held by the cache.
+	// Nil means [Msgpack]. It must be set before the first call to Add.
+	Codec Codec
+
+	// TTL is the time to live of entries added with [LRU.Add].
+	// Zero means entries do not expire.
+	TTL time.Duration
+
+	// RefreshAfter is the age after which an entry loaded by [LRU.GetOrLoad]
+	// is reloaded in the background, the stale value being served meanwhile.
+	// Zero disables refreshing.
+	RefreshAfter time.Duration
+
+	// ErrorTTL is how long [LRU.GetOrLoad] remembers that a load failed,
+	// returning the same error without calling the loader again.
+	// Zero disables caching of errors.
+	ErrorTTL time.Duration
+
 	mu         sync.RWMutex
-	nbytes     int64               // of all keys and values
-	lru        *lru.LRU[K, []byte] // any value should be allowed
+	nbytes     int64                 // of all keys and values
+	lru        lru.Cache[K, item[V]] // any value should be allowed
 	nhit, nget int64
 	nevict     int64 // number of evictions
+	ndecode    int64 // number of values that could not be decoded
+
+	calls   map[K]*call[V]     // loads in flight
+	refresh map[K]time.Time    // when loaded entries become stale
+	errs    *lru.LRU[K, error] // failed loads
 }
 
 // Add inserts a key-value pair into the cache. If the key already exists, its value
 // is updated. Returns an error if the value cannot be serialized.
 func (c *LRU[K, V]) Add(key K, value V) error {
+	return c.AddWithTTL(key, value, c.TTL)
+}
+
+// AddWithTTL is like [LRU.Add] but the entry expires after ttl.
+// If ttl is not positive the entry does not expire.
+func (c *LRU[K, V]) AddWithTTL(key K, value V, ttl time.Duration) error {
+	it, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addLocked(key, it, ttl)
+	c.forgetLocked(key)
+	return nil
+}
+
+// AddMany inserts every key-value pair of seq under a single lock, using
+// the default TTL. Nothing is added if any value cannot be serialized.
+func (c *LRU[K, V]) AddMany(seq iter.Seq2[K, V]) error {
+	var kk []K
+	var ii []item[V]
+	for key, value := range seq {
+		it, err := c.encode(value)
+		if err != nil {
+			return err
+		}
+		kk, ii = append(kk, key), append(ii, it)
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	for i, key := range kk {
+		c.addLocked(key, ii[i], c.TTL)
+		c.forgetLocked(key)
+	}
+	return nil
+}
+
+func (c *LRU[K, V]) addLocked(key K, it item[V], ttl time.Duration) {
 	if c.lru == nil {
 		// probs worth setting the [sync.Once] here?
-		c.lru = &lru.LRU[K, []byte]{
-			OnEvicted: func(key K, p []byte) {
-				c.nbytes -= int64(len(key)) + int64(len(p)) // ?
+		c.lru = newPolicy(c.Policy, c.MaxEntries, func(key K, it item[V], reason lru.Reason) {
+			c.nbytes -= size(key, it)
+			if reason == lru.Capacity || reason == lru.Expired {
 				c.nevict++
-			},
-		}
+			}
+			delete(c.refresh, key)
+		})
 	}
-	p, err := marshal(value)
-	if err != nil {
-		return fmt.Errorf("cannot convert struct to bytes slice: %w", err)
+	if old, ok := c.lru.Peek(key); ok {
+		c.nbytes -= size(key, old)
+	} else {
+		c.lru.Remove(key) // release an expired entry
+	}
+	if ttl > 0 {
+		it.expires = time.Now().Add(ttl)
+	}
+	c.nbytes += size(key, it) // before adding as it may be evicted straight away
+	c.lru.AddWithTTL(key, it, ttl)
+	if c.errs != nil {
+		c.errs.Remove(key)
+	}
+	for c.MaxBytes != 0 && c.nbytes > int64(c.MaxBytes) && c.lru.Len() > 0 {
+		c.lru.RemoveOldest()
 	}
-	c.lru.Add(key, p)
-	c.nbytes += int64(len(key)) + int64(len(p)) // is this
-	return nil
 }
 
 // Get retrieves a value from the cache by its key. Returns the value and a boolean
-// indicating whether the key was found.
+// indicating whether the key was found. A value that cannot be decoded is
+// reported as missing.
 func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	value, ok, _ = c.getLocked(key)
+	return value, ok
+}
+
+// GetMany retrieves the values of keys under a single lock, returning
+// those found and the keys that were missing, in the order they were given.
+func (c *LRU[K, V]) GetMany(keys []K) (found map[K]V, missing []K) {
+	found = make(map[K]V, len(keys))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if v, ok, _ := c.getLocked(key); ok {
+			found[key] = v
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+// getLocked looks up and decodes the value of key, reporting any error
+// from decoding it.
+func (c *LRU[K, V]) getLocked(key K) (V, bool, error) {
 	c.nget++
 	if c.lru == nil {
-		return
+		return *new(V), false, nil
 	}
-	// todo: batch get?
-	vi, ok := c.lru.Get(key)
+	it, ok := c.lru.Get(key)
 	if !ok {
-		return
+		return *new(V), false, nil
+	}
+	v, err := c.decode(it)
+	if err != nil {
+		c.ndecode++
+		return v, false, err
 	}
 	c.nhit++
-	value, _ = unmarshal[V](vi)
-	return value, true
+	return v, true, nil
 }
 
 // Remove deletes an item from the cache by its key.
 func (c *LRU[K, V]) Remove(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.forgetLocked(key)
 	if c.lru != nil {
 		c.lru.Remove(key)
 	}
+	if c.errs != nil {
+		c.errs.Remove(key)
+	}
+}
+
+// RemoveMany deletes the items of keys under a single lock, returning
+// the keys that were missing.
+func (c *LRU[K, V]) RemoveMany(keys []K) (missing []K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.forgetLocked(key)
+		if c.errs != nil {
+			c.errs.Remove(key)
+		}
+		if c.lru == nil {
+			missing = append(missing, key)
+			continue
+		}
+		if _, ok := c.lru.Peek(key); !ok {
+			missing = append(missing, key)
+		}
+		c.lru.Remove(key)
+	}
+	return missing
+}
+
+// RemovePrefix deletes every item whose key starts with prefix and
+// returns how many were removed.
+func (c *LRU[K, V]) RemovePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range withPrefix(maps.All(c.calls), prefix) {
+		c.forgetLocked(key)
+	}
+	if c.errs != nil {
+		for _, key := range withPrefix(c.errs.All(), prefix) {
+			c.errs.Remove(key)
+		}
+	}
+	if c.lru == nil {
+		return 0
+	}
+	kk := withPrefix(c.lru.Backward(), prefix)
+	for _, key := range kk {
+		c.lru.Remove(key)
+	}
+	return len(kk)
+}
+
+// withPrefix collects the keys of seq starting with prefix, so that they
+// can be removed once iteration is over.
+func withPrefix[K Key, V any](seq iter.Seq2[K, V], prefix string) []K {
+	var kk []K
+	for key := range seq {
+		if strings.HasPrefix(string(keyBytes(key)), prefix) {
+			kk = append(kk, key)
+		}
+	}
+	return kk
 }
 
 // RemoveOldest removes the least recently used item from the cache.
@@ -90,6 +265,33 @@ func (c *LRU[K, V]) RemoveOldest() {
 	}
 }
 
+// RemoveExpired removes all expired items from the cache.
+func (c *LRU[K, V]) RemoveExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lru != nil {
+		c.lru.RemoveExpired()
+	}
+	if c.errs != nil {
+		c.errs.RemoveExpired()
+	}
+}
+
+// Janitor removes expired items from the cache every interval,
+// blocking until ctx is done.
+func (c *LRU[K, V]) Janitor(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			c.RemoveExpired()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Bytes returns the approximate memory usage of the cache in bytes, including both
 // keys and serialized values.
 func (c *LRU[K, V]) Bytes() int64 {
@@ -112,13 +314,3 @@ func (c *LRU[K, V]) itemsLocked() int64 {
 	}
 	return int64(c.lru.Len())
 }
-
-func marshal[V any](value V) ([]byte, error) {
-	p, err := msgpack.Marshal(value)
-	return p, err
-}
-
-func unmarshal[V any](p []byte) (v V, err error) {
-	err = msgpack.Unmarshal(p, &v)
-	return v, err
-}
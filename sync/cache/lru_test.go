@@ -6,8 +6,15 @@
 package cache_test
 
 import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
 	"testing"
+	"testing/synctest"
+	"time"
 
+	"go.adoublef.dev/os/du"
 	. "go.adoublef.dev/sync/cache"
 )
 
@@ -54,3 +61,138 @@ func Test_Cache_Get(t *testing.T) {
 		}
 	})
 }
+
+func Test_Cache_TTL(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			c := LRU[string, string]{TTL: time.Minute}
+			_ = c.Add("1", "Hello")
+			_ = c.AddWithTTL("2", "World", 0)
+
+			time.Sleep(time.Minute)
+			if _, ok := c.Get("1"); ok {
+				t.Errorf("using key %q should have expired", "1")
+			}
+			if _, ok := c.Get("2"); !ok {
+				t.Errorf("using key %q should have a value", "2")
+			}
+			if c.Items() != 1 {
+				t.Error("unexpected items")
+			}
+		})
+	})
+
+	t.Run("Janitor", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var c LRU[string, string]
+			_ = c.AddWithTTL("1", "Hello", time.Second)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			go c.Janitor(ctx, time.Second)
+
+			time.Sleep(2 * time.Second)
+			synctest.Wait()
+			if c.Items() != 0 || c.Bytes() != 0 {
+				t.Error("expired entry should have been removed")
+			}
+			cancel()
+		})
+	})
+}
+
+func Test_Cache_Policy(t *testing.T) {
+	for _, p := range []Policy{PolicyLRU, Policy2Q, PolicyARC, PolicyTinyLFU} {
+		t.Run(p.String(), func(t *testing.T) {
+			c := LRU[string, int]{MaxEntries: 10, Policy: p}
+			for i := range 100 {
+				_ = c.Add(strconv.Itoa(i), i)
+			}
+			if c.Items() != 10 {
+				t.Errorf("got %d items; want %d", c.Items(), 10)
+			}
+			_ = c.Add("hello", 1)
+			if v, ok := c.Get("hello"); !ok || v != 1 {
+				t.Errorf("using key %q should have a value", "hello")
+			}
+		})
+	}
+}
+
+func Test_Cache_MaxBytes(t *testing.T) {
+	c := LRU[string, string]{MaxBytes: 64 * du.B}
+	for i := range 100 {
+		_ = c.Add(strconv.Itoa(i), "Hello")
+		if c.Bytes() > 64 {
+			t.Fatalf("got %d bytes; want at most 64", c.Bytes())
+		}
+	}
+	// "99" and the encoded "Hello" take 8 bytes
+	if c.Items() != 8 {
+		t.Errorf("got %d items; want %d", c.Items(), 8)
+	}
+	if _, ok := c.Get("99"); !ok {
+		t.Errorf("using key %q should have a value", "99")
+	}
+
+	// replacing a value should not count its key twice
+	_ = c.Add("99", "Hello")
+	if c.Bytes() != 64 {
+		t.Errorf("got %d bytes; want %d", c.Bytes(), 64)
+	}
+}
+
+func Test_Cache_RemovePrefix(t *testing.T) {
+	var c LRU[string, int]
+	for _, key := range []string{"user:1", "user:2", "team:1"} {
+		_ = c.Add(key, 1)
+	}
+	if n := c.RemovePrefix("user:"); n != 2 {
+		t.Fatalf("got %d removed; want 2", n)
+	}
+	if _, ok := c.Get("user:1"); ok {
+		t.Fatal("returned a removed entry")
+	}
+	if _, ok := c.Get("team:1"); !ok || c.Bytes() != 7 {
+		t.Fatalf("got %t, %d bytes; want true, 7 bytes", ok, c.Bytes())
+	}
+}
+
+func Test_Cache_Many(t *testing.T) {
+	var c LRU[string, int]
+	err := c.AddMany(maps.All(map[string]int{"a": 1, "b": 2, "c": 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, missing := c.GetMany([]string{"a", "x", "c", "y"})
+	if !maps.Equal(found, map[string]int{"a": 1, "c": 3}) || !slices.Equal(missing, []string{"x", "y"}) {
+		t.Fatalf("got %v, %v; want map[a:1 c:3], [x y]", found, missing)
+	}
+	if missing := c.RemoveMany([]string{"a", "x", "b"}); !slices.Equal(missing, []string{"x"}) {
+		t.Fatalf("got %v missing; want [x]", missing)
+	}
+	if c.Items() != 1 || c.Bytes() != 2 {
+		t.Fatalf("got %d items and %d bytes; want 1 and 2", c.Items(), c.Bytes())
+	}
+
+	t.Run("Sharded", func(t *testing.T) {
+		c := NewSharded[string, int](4)
+		m := make(map[string]int)
+		var keys []string
+		for i := range 20 {
+			m[strconv.Itoa(i)] = i
+			keys = append(keys, strconv.Itoa(i))
+		}
+		if err := c.AddMany(maps.All(m)); err != nil {
+			t.Fatal(err)
+		}
+		found, missing := c.GetMany(append(keys, "x"))
+		if !maps.Equal(found, m) || !slices.Equal(missing, []string{"x"}) {
+			t.Fatalf("got %v, %v; want %v, [x]", found, missing, m)
+		}
+		missing = c.RemoveMany(append(keys, "x", "y"))
+		slices.Sort(missing)
+		if c.Items() != 0 || !slices.Equal(missing, []string{"x", "y"}) {
+			t.Fatalf("got %d items and %v missing; want 0 and [x y]", c.Items(), missing)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultPath is the path under which peers serve their keys over HTTP.
+const DefaultPath = "/_peer/"
+
+// statusLoadFailed is sent by [Handler] when the owner's loader fails, so
+// that it is not mistaken for an error from the server or a proxy.
+const statusLoadFailed = http.StatusFailedDependency
+
+// HTTPTransport is a [Transport] fetching keys from peers over HTTP. Peers
+// are the base URLs of servers running [Handler].
+type HTTPTransport struct {
+	// Client is used to make requests. Nil means [http.DefaultClient].
+	Client *http.Client
+
+	// Path is the path the peers serve keys under. Empty means [DefaultPath].
+	Path string
+}
+
+// Fetch implements [Transport]. An error from the owner's loader is returned
+// with the same message, though not the same identity, as the original.
+func (t *HTTPTransport) Fetch(ctx context.Context, peer, key string) ([]byte, error) {
+	u := strings.TrimSuffix(peer, "/") + t.path() + url.PathEscape(key)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request: %w", err)
+	}
+	c := t.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	res, err := c.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch %q from %s: %w: %w", key, peer, ErrUnreachable, err)
+	}
+	defer res.Body.Close()
+	p, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q from %s: %w: %w", key, peer, ErrUnreachable, err)
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		return p, nil
+	case statusLoadFailed:
+		return nil, errors.New(strings.TrimSuffix(string(p), "\n"))
+	default:
+		return nil, fmt.Errorf("cannot fetch %q from %s: %w: %s", key, peer, ErrUnreachable, res.Status)
+	}
+}
+
+func (t *HTTPTransport) path() string {
+	if t.Path == "" {
+		return DefaultPath
+	}
+	return t.Path
+}
+
+// Handler serves the keys owned by s to other peers, expecting to be
+// mounted at the path used by their [HTTPTransport]. Empty means [DefaultPath].
+func Handler(path string, s Server) http.Handler {
+	if path == "" {
+		path = DefaultPath
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		key, ok := strings.CutPrefix(r.URL.Path, path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		p, err := s.Local(r.Context(), key)
+		if err != nil {
+			http.Error(w, err.Error(), statusLoadFailed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(p)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// LocalTransport is a [Transport] connecting groups within the same
+// process, so that a set of peers can be exercised without a network.
+// The zero value is ready to use.
+type LocalTransport struct {
+	mu     sync.RWMutex
+	peers  map[string]Server
+	nfetch int64
+}
+
+// Register makes s reachable as peer.
+func (t *LocalTransport) Register(peer string, s Server) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.peers == nil {
+		t.peers = make(map[string]Server)
+	}
+	t.peers[peer] = s
+}
+
+// Unregister makes peer unreachable, as if it were down.
+func (t *LocalTransport) Unregister(peer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.peers, peer)
+}
+
+// Fetch implements [Transport].
+func (t *LocalTransport) Fetch(ctx context.Context, peer, key string) ([]byte, error) {
+	t.mu.Lock()
+	s, ok := t.peers[peer]
+	t.nfetch++
+	t.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("cannot fetch %q from %s: %w", key, peer, ErrUnreachable)
+	}
+	return s.Local(ctx, key)
+}
+
+// Fetches returns the number of calls to Fetch.
+func (t *LocalTransport) Fetches() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.nfetch
+}
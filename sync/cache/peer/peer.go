@@ -0,0 +1,159 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package peer provides a cache shared by a group of peers, in the style of
+// groupcache. Each key is owned by one peer, chosen by consistent hashing,
+// which loads and caches its value. Other peers fetch the value from the
+// owner and keep local copies of the keys they request most often.
+package peer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync"
+
+	"go.adoublef.dev/container/consistenthash"
+	"go.adoublef.dev/sync/cache"
+)
+
+// Transport fetches the encoded value of a key from the peer that owns it.
+// Errors that mean the owner could not be asked wrap [ErrUnreachable],
+// while an error from the owner's loader is returned as is.
+type Transport interface {
+	Fetch(ctx context.Context, peer, key string) ([]byte, error)
+}
+
+// ErrUnreachable is returned by a [Transport] that could not get an answer
+// from the owner of a key.
+var ErrUnreachable = errors.New("peer unreachable")
+
+// Server serves the encoded values of the keys owned by a peer.
+// It is implemented by [Group].
+type Server interface {
+	Local(ctx context.Context, key string) ([]byte, error)
+}
+
+// Group is a cache whose keys are each owned by one of a set of peers.
+type Group[V any] struct {
+	// Main holds the values of the keys owned by this peer.
+	Main cache.LRU[string, V]
+
+	// Hot holds copies of values owned by other peers. By default it keeps
+	// the 1024 keys requested most often, as judged by [cache.PolicyTinyLFU].
+	// Copies are not invalidated when the owner's value changes, so setting
+	// a TTL bounds how stale they can be.
+	Hot cache.LRU[string, V]
+
+	// Codec converts values to and from the bytes sent between peers.
+	// Nil means [cache.Msgpack].
+	Codec cache.Codec
+
+	// Replicas is the number of points each peer has on the hash ring.
+	// It must be set before the first call to SetPeers.
+	Replicas int
+
+	self      string
+	transport Transport
+	load      cache.LoadFunc[string, V]
+
+	mu   sync.RWMutex
+	ring *consistenthash.Map
+}
+
+// NewGroup creates a new [Group] for the peer at self, fetching keys owned by
+// other peers using t. The owner of a key calls load when it is not cached.
+func NewGroup[V any](self string, t Transport, load cache.LoadFunc[string, V], opts ...func(*Group[V])) *Group[V] {
+	assert(t != nil, "transport cannot be nil")
+	assert(load != nil, "load cannot be nil")
+
+	g := &Group[V]{self: self, transport: t, load: load, Replicas: 50}
+	g.Hot.MaxEntries = 1024
+	g.Hot.Policy = cache.PolicyTinyLFU
+	for _, o := range opts {
+		o(g)
+	}
+	return g
+}
+
+// SetPeers replaces the peers sharing the cache. The list should include
+// this peer and be the same on every peer.
+func (g *Group[V]) SetPeers(peers ...string) {
+	ring := consistenthash.New(g.Replicas, consistenthash.HashFunc(crc32.ChecksumIEEE))
+	ring.Add(peers...)
+	g.mu.Lock()
+	g.ring = ring
+	g.mu.Unlock()
+}
+
+// Owner returns the peer that owns key. Without any peers it is this one.
+func (g *Group[V]) Owner(key string) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.ring == nil || g.ring.IsEmpty() {
+		return g.self
+	}
+	return g.ring.Get(key)
+}
+
+// Get returns the value of key. Keys owned by this peer are read from Main,
+// calling the loader on a miss. Other keys are read from Hot or fetched from
+// their owner, falling back to the loader if the owner cannot be reached.
+// An error from the owner's loader is returned without loading the key again.
+func (g *Group[V]) Get(ctx context.Context, key string) (V, error) {
+	owner := g.Owner(key)
+	if owner == g.self {
+		return g.Main.GetOrLoad(ctx, key, g.load)
+	}
+	return g.Hot.GetOrLoad(ctx, key, func(ctx context.Context, key string) (V, error) {
+		p, err := g.transport.Fetch(ctx, owner, key)
+		if errors.Is(err, ErrUnreachable) {
+			return g.load(ctx, key)
+		}
+		if err != nil {
+			return *new(V), err
+		}
+		var v V
+		err = g.codec().Unmarshal(p, &v)
+		if err != nil {
+			return v, fmt.Errorf("cannot convert bytes slice to struct: %w", err)
+		}
+		return v, nil
+	})
+}
+
+// Local returns the encoded value of a key owned by this peer, loading it
+// on a miss. Transports use it to serve requests from other peers.
+func (g *Group[V]) Local(ctx context.Context, key string) ([]byte, error) {
+	v, err := g.Main.GetOrLoad(ctx, key, g.load)
+	if err != nil {
+		return nil, err
+	}
+	p, err := g.codec().Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert struct to bytes slice: %w", err)
+	}
+	return p, nil
+}
+
+// Remove deletes key from this peer's caches.
+func (g *Group[V]) Remove(key string) {
+	g.Main.Remove(key)
+	g.Hot.Remove(key)
+}
+
+func (g *Group[V]) codec() cache.Codec {
+	if g.Codec == nil {
+		return cache.Msgpack
+	}
+	return g.Codec
+}
+
+func assert(exp bool, format string) {
+	if !exp {
+		panic(format)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package peer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	. "go.adoublef.dev/sync/cache/peer"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		var loads atomic.Int64
+		load := func(_ context.Context, key string) (string, error) {
+			loads.Add(1)
+			return "value of " + key, nil
+		}
+
+		var tr LocalTransport
+		peers := []string{"a", "b", "c"}
+		groups := make(map[string]*Group[string])
+		for _, p := range peers {
+			g := NewGroup(p, &tr, load)
+			g.SetPeers(peers...)
+			tr.Register(p, g)
+			groups[p] = g
+		}
+
+		for i := range 30 {
+			key := strconv.Itoa(i)
+			for _, p := range peers {
+				v, err := groups[p].Get(t.Context(), key)
+				if err != nil || v != "value of "+key {
+					t.Fatalf("got %q, %v; want %q, nil", v, err, "value of "+key)
+				}
+			}
+		}
+		if n := loads.Load(); n != 30 {
+			t.Fatalf("got %d loads; want 30, one by each owner", n)
+		}
+		if n := tr.Fetches(); n != 60 {
+			t.Fatalf("got %d fetches; want 60, one by each other peer", n)
+		}
+
+		// copies are served without fetching again
+		for i := range 30 {
+			for _, p := range peers {
+				_, _ = groups[p].Get(t.Context(), strconv.Itoa(i))
+			}
+		}
+		if n := tr.Fetches(); n != 60 {
+			t.Fatalf("got %d fetches; want 60", n)
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		var loads atomic.Int64
+		load := func(_ context.Context, key string) (int, error) {
+			loads.Add(1)
+			return len(key), nil
+		}
+
+		var tr LocalTransport
+		a, b := NewGroup("a", &tr, load), NewGroup("b", &tr, load)
+		a.SetPeers("a", "b")
+		b.SetPeers("a", "b")
+		tr.Register("a", a) // b is down
+
+		key := "0"
+		for a.Owner(key) != "b" {
+			key += "0"
+		}
+		if v, err := a.Get(t.Context(), key); err != nil || v != len(key) {
+			t.Fatalf("got %d, %v; want %d, nil", v, err, len(key))
+		}
+		if loads.Load() != 1 {
+			t.Fatal("did not fall back to the loader")
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		errLoad := errors.New("cannot load")
+		var tr LocalTransport
+		g := NewGroup("a", &tr, func(context.Context, string) (int, error) { return 0, errLoad })
+		if _, err := g.Get(t.Context(), "1"); !errors.Is(err, errLoad) {
+			t.Fatalf("got %v; want %v", err, errLoad)
+		}
+	})
+
+	t.Run("RemoteError", func(t *testing.T) {
+		errNotFound := errors.New("not found")
+		var loads atomic.Int64
+		load := func(context.Context, string) (int, error) {
+			loads.Add(1)
+			return 0, errNotFound
+		}
+
+		var tr LocalTransport
+		a, b := NewGroup("a", &tr, load), NewGroup("b", &tr, load)
+		a.SetPeers("a", "b")
+		b.SetPeers("a", "b")
+		tr.Register("a", a)
+		tr.Register("b", b)
+
+		key := "0"
+		for a.Owner(key) != "b" {
+			key += "0"
+		}
+		if _, err := a.Get(t.Context(), key); !errors.Is(err, errNotFound) {
+			t.Fatalf("got %v; want %v", err, errNotFound)
+		}
+		if n := loads.Load(); n != 1 {
+			t.Fatalf("got %d loads; want 1, by the owner only", n)
+		}
+	})
+}
+
+func TestHTTPTransport(t *testing.T) {
+	var loads atomic.Int64
+	load := func(_ context.Context, key string) (string, error) {
+		loads.Add(1)
+		if key == "missing" {
+			return "", errors.New("not found")
+		}
+		return "value of " + key, nil
+	}
+
+	var groups []*Group[string]
+	var peers []string
+	for range 2 {
+		mux := http.NewServeMux()
+		ts := httptest.NewServer(mux)
+		t.Cleanup(ts.Close)
+		g := NewGroup(ts.URL, &HTTPTransport{Client: ts.Client()}, load)
+		mux.Handle(DefaultPath, Handler("", g))
+		groups = append(groups, g)
+		peers = append(peers, ts.URL)
+	}
+	for _, g := range groups {
+		g.SetPeers(peers...)
+	}
+
+	for _, key := range []string{"1", "a/b", "with space"} {
+		for _, g := range groups {
+			v, err := g.Get(t.Context(), key)
+			if err != nil || v != "value of "+key {
+				t.Fatalf("got %q, %v; want %q, nil", v, err, "value of "+key)
+			}
+		}
+	}
+	if n := loads.Load(); n != 3 {
+		t.Fatalf("got %d loads; want 3, one by each owner", n)
+	}
+
+	// a load error comes back from the owner rather than being retried
+	for _, g := range groups {
+		_, err := g.Get(t.Context(), "missing")
+		if err == nil || err.Error() != "not found" {
+			t.Fatalf("got %v; want not found", err)
+		}
+	}
+	if n := loads.Load(); n != 5 {
+		t.Fatalf("got %d loads; want 5, the owner loading once for each peer", n)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import "go.adoublef.dev/container/lru"
+
+// Policy selects how an [LRU] chooses entries to evict once it holds
+// MaxEntries items.
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used entry.
+	PolicyLRU Policy = iota
+	// Policy2Q evicts using [lru.TwoQueue].
+	Policy2Q
+	// PolicyARC evicts using [lru.ARC].
+	PolicyARC
+	// PolicyTinyLFU evicts using [lru.TinyLFU].
+	PolicyTinyLFU
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "lru"
+	case Policy2Q:
+		return "2q"
+	case PolicyARC:
+		return "arc"
+	case PolicyTinyLFU:
+		return "tinylfu"
+	}
+	return "unknown"
+}
+
+// newPolicy returns the cache for policy p. Policies other than
+// [PolicyLRU] need a bound so an unbounded cache is always an LRU.
+func newPolicy[K comparable, V any](p Policy, maxEntries int, onEvicted func(K, V, lru.Reason)) lru.Cache[K, V] {
+	if maxEntries <= 0 {
+		p = PolicyLRU
+	}
+	switch p {
+	case Policy2Q:
+		c := lru.New2Q[K, V](maxEntries)
+		c.OnEvicted = onEvicted
+		return c
+	case PolicyARC:
+		c := lru.NewARC[K, V](maxEntries)
+		c.OnEvicted = onEvicted
+		return c
+	case PolicyTinyLFU:
+		c := lru.NewTinyLFU[K, V](maxEntries)
+		c.OnEvicted = onEvicted
+		return c
+	default:
+		c := lru.New[K, V](max(0, maxEntries))
+		c.OnEvicted = onEvicted
+		return c
+	}
+}
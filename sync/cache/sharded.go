@@ -0,0 +1,177 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"hash/maphash"
+	"iter"
+	"maps"
+	"time"
+)
+
+// Sharded is a cache split into independent [LRU] shards by the hash of
+// each key, so that callers using different keys rarely contend on the
+// same lock.
+type Sharded[K Key, V any] struct {
+	seed   maphash.Seed
+	shards []*LRU[K, V]
+}
+
+// NewSharded creates a new [Sharded] cache of n shards. Each shard is
+// configured by opts, so limits such as MaxEntries and MaxBytes apply to
+// every shard rather than to the cache as a whole.
+func NewSharded[K Key, V any](n int, opts ...func(*LRU[K, V])) *Sharded[K, V] {
+	assert(n > 0, "n must be positive")
+
+	s := &Sharded[K, V]{seed: maphash.MakeSeed(), shards: make([]*LRU[K, V], n)}
+	for i := range s.shards {
+		c := new(LRU[K, V])
+		for _, o := range opts {
+			o(c)
+		}
+		s.shards[i] = c
+	}
+	return s
+}
+
+func (s *Sharded[K, V]) shard(key K) *LRU[K, V] {
+	return s.shards[maphash.Comparable(s.seed, key)%uint64(len(s.shards))]
+}
+
+// Add inserts a key-value pair into the shard owning key. See [LRU.Add].
+func (s *Sharded[K, V]) Add(key K, value V) error { return s.shard(key).Add(key, value) }
+
+// AddWithTTL is like [Sharded.Add] but the entry expires after ttl.
+func (s *Sharded[K, V]) AddWithTTL(key K, value V, ttl time.Duration) error {
+	return s.shard(key).AddWithTTL(key, value, ttl)
+}
+
+// Get retrieves a value from the shard owning key. See [LRU.Get].
+func (s *Sharded[K, V]) Get(key K) (V, bool) { return s.shard(key).Get(key) }
+
+// GetOrLoad retrieves a value from the shard owning key, loading it on a
+// miss. See [LRU.GetOrLoad].
+func (s *Sharded[K, V]) GetOrLoad(ctx context.Context, key K, load LoadFunc[K, V]) (V, error) {
+	return s.shard(key).GetOrLoad(ctx, key, load)
+}
+
+// GetMany retrieves the values of keys, taking the lock of each shard
+// once. Unlike [LRU.GetMany] the missing keys are in no particular order.
+func (s *Sharded[K, V]) GetMany(keys []K) (found map[K]V, missing []K) {
+	found = make(map[K]V, len(keys))
+	for c, kk := range s.group(keys) {
+		f, m := c.GetMany(kk)
+		maps.Copy(found, f)
+		missing = append(missing, m...)
+	}
+	return found, missing
+}
+
+// AddMany inserts every key-value pair of seq, taking the lock of each
+// shard once. A value that cannot be serialized stops it, though other
+// shards may have been added to already.
+func (s *Sharded[K, V]) AddMany(seq iter.Seq2[K, V]) error {
+	type pair struct {
+		key   K
+		value V
+	}
+	m := make(map[*LRU[K, V]][]pair)
+	for key, value := range seq {
+		c := s.shard(key)
+		m[c] = append(m[c], pair{key, value})
+	}
+	for c, pp := range m {
+		err := c.AddMany(func(yield func(K, V) bool) {
+			for _, p := range pp {
+				if !yield(p.key, p.value) {
+					return
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveMany deletes the items of keys, taking the lock of each shard
+// once, and returns the keys that were missing in no particular order.
+func (s *Sharded[K, V]) RemoveMany(keys []K) (missing []K) {
+	for c, kk := range s.group(keys) {
+		missing = append(missing, c.RemoveMany(kk)...)
+	}
+	return missing
+}
+
+// group splits keys by the shard owning them.
+func (s *Sharded[K, V]) group(keys []K) map[*LRU[K, V]][]K {
+	m := make(map[*LRU[K, V]][]K)
+	for _, key := range keys {
+		c := s.shard(key)
+		m[c] = append(m[c], key)
+	}
+	return m
+}
+
+// Remove deletes an item from the shard owning key.
+func (s *Sharded[K, V]) Remove(key K) { s.shard(key).Remove(key) }
+
+// RemovePrefix deletes every item whose key starts with prefix from
+// every shard and returns how many were removed.
+func (s *Sharded[K, V]) RemovePrefix(prefix string) (n int) {
+	for _, c := range s.shards {
+		n += c.RemovePrefix(prefix)
+	}
+	return n
+}
+
+// RemoveExpired removes all expired items from every shard.
+func (s *Sharded[K, V]) RemoveExpired() {
+	for _, c := range s.shards {
+		c.RemoveExpired()
+	}
+}
+
+// Janitor removes expired items from every shard each interval,
+// blocking until ctx is done.
+func (s *Sharded[K, V]) Janitor(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.RemoveExpired()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Bytes returns the approximate memory usage of all shards in bytes.
+func (s *Sharded[K, V]) Bytes() int64 {
+	var n int64
+	for _, c := range s.shards {
+		n += c.Bytes()
+	}
+	return n
+}
+
+// Items returns the number of items stored across all shards.
+func (s *Sharded[K, V]) Items() int64 {
+	var n int64
+	for _, c := range s.shards {
+		n += c.Items()
+	}
+	return n
+}
+
+func assert(exp bool, format string) {
+	if !exp {
+		panic(format)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	. "go.adoublef.dev/sync/cache"
+	"go.adoublef.dev/testing/is"
+)
+
+func Test_Sharded(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := NewSharded[string, int](8)
+
+		var wg sync.WaitGroup
+		for i := range 1000 {
+			wg.Go(func() {
+				key := strconv.Itoa(i)
+				is.OK(t, c.Add(key, i))
+				v, ok := c.Get(key)
+				is.True(t, ok)
+				is.Equal(t, v, i)
+			})
+		}
+		wg.Wait()
+		is.Equal(t, c.Items(), int64(1000))
+
+		c.Remove("1")
+		_, ok := c.Get("1")
+		is.True(t, !ok)
+		is.Equal(t, c.Items(), int64(999))
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		c := NewSharded(4, func(c *LRU[string, int]) { c.MaxEntries = 10 })
+		for i := range 1000 {
+			is.OK(t, c.Add(strconv.Itoa(i), i))
+		}
+		is.Equal(t, c.Items(), int64(40))
+	})
+}
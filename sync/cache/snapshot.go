@@ -0,0 +1,242 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// WriteTo implements io.WriterTo. Unexpired entries are written from the
+// one the eviction policy would evict first to the one it would evict last,
+// each with its serialized value and expiry, so that [LRU.ReadFrom] can
+// restore them in the same order. Values stored with the [Pointer] codec
+// cannot be written.
+func (c *LRU[K, V]) WriteTo(w io.Writer) (n int64, err error) {
+	type record struct {
+		key     K
+		p       []byte
+		expires int64
+	}
+	c.mu.RLock()
+	var rr []record
+	if c.lru != nil {
+		for key, it := range c.lru.Backward() {
+			if it.ptr {
+				c.mu.RUnlock()
+				return 0, errors.New("cannot encode values stored with the Pointer codec")
+			}
+			var expires int64
+			if !it.expires.IsZero() {
+				expires = it.expires.UnixNano()
+			}
+			rr = append(rr, record{key, it.p, expires})
+		}
+	}
+	c.mu.RUnlock()
+
+	err = binary.Write(w, binary.LittleEndian, int64(len(rr)))
+	if err != nil {
+		return n, fmt.Errorf("cannot encode number of entries: %w", err)
+	}
+	n += 8
+	for _, r := range rr {
+		m, err := writeBytes(w, keyBytes(r.key))
+		if n += m; err != nil {
+			return n, fmt.Errorf("cannot encode key: %w", err)
+		}
+		err = binary.Write(w, binary.LittleEndian, r.expires)
+		if err != nil {
+			return n, fmt.Errorf("cannot encode expiry: %w", err)
+		}
+		n += 8
+		m, err = writeBytes(w, r.p)
+		if n += m; err != nil {
+			return n, fmt.Errorf("cannot encode value: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// ReadFrom implements io.ReaderFrom, adding the entries written by
+// [LRU.WriteTo] to the cache in order. Entries that have since expired are
+// dropped and the others keep their remaining time to live. The values must
+// have been written using the same [Codec] as the cache.
+func (c *LRU[K, V]) ReadFrom(r io.Reader) (n int64, err error) {
+	if _, ok := c.codec().(pointerCodec); ok {
+		return 0, errors.New("cannot decode values stored with the Pointer codec")
+	}
+	var count int64
+	err = binary.Read(r, binary.LittleEndian, &count)
+	if err != nil {
+		return n, fmt.Errorf("cannot decode number of entries: %w", err)
+	}
+	n += 8
+	if count < 0 {
+		return n, fmt.Errorf("cannot decode entries: invalid number %d", count)
+	}
+	type record struct {
+		key K
+		it  item[V]
+		ttl time.Duration
+	}
+	var rr []record
+	for range count {
+		p, m, err := readBytes(r)
+		if n += m; err != nil {
+			return n, fmt.Errorf("cannot decode key: %w", err)
+		}
+		key, err := keyFrom[K](p)
+		if err != nil {
+			return n, err
+		}
+		var expires int64
+		err = binary.Read(r, binary.LittleEndian, &expires)
+		if err != nil {
+			return n, fmt.Errorf("cannot decode expiry: %w", err)
+		}
+		n += 8
+		p, m, err = readBytes(r)
+		if n += m; err != nil {
+			return n, fmt.Errorf("cannot decode value: %w", err)
+		}
+		var ttl time.Duration
+		if expires != 0 {
+			if ttl = time.Until(time.Unix(0, expires)); ttl <= 0 {
+				continue
+			}
+		}
+		rr = append(rr, record{key, item[V]{p: p}, ttl})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range rr {
+		c.addLocked(r.key, r.it, r.ttl)
+		c.forgetLocked(r.key)
+	}
+	return n, nil
+}
+
+// Snapshot restores the cache from the file called name, if there is one,
+// then writes the cache to it every interval, blocking until ctx is done
+// when it is written one last time. Each write is synced to disk and then
+// replaces the file atomically, so that a crash or power loss leaves either
+// the previous snapshot or the new one, never a partial one.
+func (c *LRU[K, V]) Snapshot(ctx context.Context, name string, interval time.Duration) error {
+	if err := c.readFile(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := c.writeFile(name); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return c.writeFile(name)
+		}
+	}
+}
+
+func (c *LRU[K, V]) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = c.ReadFrom(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("cannot read snapshot %q: %w", name, err)
+	}
+	return nil
+}
+
+func (c *LRU[K, V]) writeFile(name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %w", err)
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+	w := bufio.NewWriter(f)
+	if _, err = c.WriteTo(w); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync() // before the rename, so that it cannot expose a truncated file
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write snapshot %q: %w", name, err)
+	}
+	err = os.Rename(f.Name(), name)
+	if err != nil {
+		return fmt.Errorf("cannot replace snapshot %q: %w", name, err)
+	}
+	return nil
+}
+
+func writeBytes(w io.Writer, p []byte) (int64, error) {
+	err := binary.Write(w, binary.LittleEndian, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	m, err := w.Write(p)
+	return 8 + int64(m), err
+}
+
+func readBytes(r io.Reader) ([]byte, int64, error) {
+	var sz int64
+	err := binary.Read(r, binary.LittleEndian, &sz)
+	if err != nil {
+		return nil, 0, err
+	}
+	if sz < 0 {
+		return nil, 8, fmt.Errorf("invalid size %d", sz)
+	}
+	p := make([]byte, sz)
+	m, err := io.ReadFull(r, p)
+	return p, 8 + int64(m), err
+}
+
+// keyBytes returns the bytes of key, whichever of the [Key] types it is.
+func keyBytes[K Key](key K) []byte {
+	v := reflect.ValueOf(&key).Elem()
+	if v.Kind() == reflect.String {
+		return []byte(v.String())
+	}
+	return append([]byte(nil), v.Bytes()...)
+}
+
+// keyFrom is the inverse of [keyBytes].
+func keyFrom[K Key](p []byte) (key K, err error) {
+	v := reflect.ValueOf(&key).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(p))
+	case reflect.Slice:
+		v.SetBytes(p)
+	case reflect.Array:
+		if len(p) != v.Len() {
+			return key, fmt.Errorf("cannot decode key: got %d bytes; want %d", len(p), v.Len())
+		}
+		reflect.Copy(v, reflect.ValueOf(p))
+	}
+	return key, nil
+}
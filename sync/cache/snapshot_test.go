@@ -0,0 +1,107 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "go.adoublef.dev/sync/cache"
+)
+
+func Test_LRU_WriteTo(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var c LRU[string, string]
+		_ = c.Add("a", "A")
+		_ = c.AddWithTTL("b", "B", time.Second)
+		_ = c.AddWithTTL("c", "C", time.Hour)
+		c.Get("a")
+
+		var buf bytes.Buffer
+		n, err := c.WriteTo(&buf)
+		if err != nil || n != int64(buf.Len()) {
+			t.Fatalf("got %d, %v; want %d, nil", n, err, buf.Len())
+		}
+		time.Sleep(2 * time.Second)
+
+		var d LRU[string, string]
+		if _, err := d.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if d.Items() != 2 {
+			t.Fatalf("got %d items; want 2 without the expired entry", d.Items())
+		}
+		d.RemoveOldest()
+		if _, ok := d.Get("c"); ok {
+			t.Fatal("did not restore entries in recency order")
+		}
+		if v, ok := d.Get("a"); !ok || v != "A" {
+			t.Fatalf("got %q, %t; want %q, true", v, ok, "A")
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var c LRU[[16]byte, int]
+			_ = c.AddWithTTL([16]byte{1}, 1, time.Hour)
+
+			var buf bytes.Buffer
+			_, _ = c.WriteTo(&buf)
+			var d LRU[[16]byte, int]
+			if _, err := d.ReadFrom(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if v, ok := d.Get([16]byte{1}); !ok || v != 1 {
+				t.Fatalf("got %d, %t; want 1, true", v, ok)
+			}
+			time.Sleep(time.Hour)
+			if _, ok := d.Get([16]byte{1}); ok {
+				t.Fatal("restored entry outlived its ttl")
+			}
+		})
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		c := LRU[string, int]{Codec: Pointer}
+		_ = c.Add("a", 1)
+		if _, err := c.WriteTo(new(bytes.Buffer)); err == nil {
+			t.Fatal("wrote values stored with the Pointer codec")
+		}
+	})
+}
+
+func Test_LRU_Snapshot(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cache")
+	synctest.Test(t, func(t *testing.T) {
+		var c LRU[string, string]
+		_ = c.Add("a", "A")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		done := make(chan error)
+		go func() { done <- c.Snapshot(ctx, name, time.Minute) }()
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		_ = c.Add("b", "B")
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var d LRU[string, string]
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := d.Snapshot(ctx, name, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d.Items() != 2 {
+		t.Fatalf("got %d items; want 2", d.Items())
+	}
+}
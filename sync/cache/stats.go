@@ -0,0 +1,61 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// Stats is a snapshot of the counters of a cache.
+type Stats struct {
+	Gets      int64 // number of lookups
+	Hits      int64 // number of lookups that found a value
+	Misses    int64 // number of lookups that did not
+	Evictions int64 // number of entries evicted by capacity or expiry
+	Decodes   int64 // number of values that could not be decoded
+	Bytes     int64 // size of all keys and serialized values
+	Items     int64 // number of entries
+}
+
+// HitRatio returns the fraction of lookups that found a value,
+// or zero if there have been none.
+func (s Stats) HitRatio() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Gets)
+}
+
+func (s Stats) add(t Stats) Stats {
+	return Stats{
+		Gets:      s.Gets + t.Gets,
+		Hits:      s.Hits + t.Hits,
+		Misses:    s.Misses + t.Misses,
+		Evictions: s.Evictions + t.Evictions,
+		Decodes:   s.Decodes + t.Decodes,
+		Bytes:     s.Bytes + t.Bytes,
+		Items:     s.Items + t.Items,
+	}
+}
+
+// Stats returns a snapshot of the counters of the cache.
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Stats{
+		Gets:      c.nget,
+		Hits:      c.nhit,
+		Misses:    c.nget - c.nhit,
+		Evictions: c.nevict,
+		Decodes:   c.ndecode,
+		Bytes:     c.nbytes,
+		Items:     c.itemsLocked(),
+	}
+}
+
+// Stats returns the sum of the counters of every shard.
+func (s *Sharded[K, V]) Stats() (st Stats) {
+	for _, c := range s.shards {
+		st = st.add(c.Stats())
+	}
+	return st
+}
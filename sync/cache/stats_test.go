@@ -0,0 +1,94 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	. "go.adoublef.dev/sync/cache"
+)
+
+func Test_Stats(t *testing.T) {
+	t.Run("LRU", func(t *testing.T) {
+		c := LRU[string, string]{MaxEntries: 2}
+		for i := range 3 {
+			_ = c.Add(strconv.Itoa(i), "Hello")
+		}
+		c.Get("0") // evicted
+		c.Get("1")
+		c.Get("2")
+		c.Get("2")
+
+		s := c.Stats()
+		want := Stats{Gets: 4, Hits: 3, Misses: 1, Evictions: 1, Bytes: 14, Items: 2}
+		if s != want {
+			t.Fatalf("got %+v; want %+v", s, want)
+		}
+		if s.HitRatio() != 0.75 {
+			t.Fatalf("got %v hit ratio; want 0.75", s.HitRatio())
+		}
+	})
+
+	t.Run("Sharded", func(t *testing.T) {
+		c := NewSharded[string, string](4)
+		for i := range 10 {
+			_ = c.Add(strconv.Itoa(i), "Hello")
+			c.Get(strconv.Itoa(i))
+		}
+		c.Get("missing")
+		s := c.Stats()
+		if s.Gets != 11 || s.Hits != 10 || s.Items != 10 || s.Bytes != c.Bytes() {
+			t.Fatalf("got %+v; want 11 gets, 10 hits and 10 items", s)
+		}
+	})
+}
+
+func Test_Collector(t *testing.T) {
+	var a, b LRU[string, string]
+	_ = a.Add("1", "Hello")
+	a.Get("1")
+	b.Get("1")
+
+	var col Collector
+	col.Add("a", &a)
+	col.Add("b", &b)
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(&col); err != nil {
+		t.Fatal(err)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]float64)
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName() + "{" + m.GetLabel()[0].GetValue() + "}"
+			switch {
+			case m.Counter != nil:
+				got[key] = m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				got[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	for key, want := range map[string]float64{
+		"cache_gets_total{a}":   1,
+		"cache_hits_total{a}":   1,
+		"cache_misses_total{b}": 1,
+		"cache_items{a}":        1,
+		"cache_bytes{a}":        7,
+		"cache_hit_ratio{a}":    1,
+		"cache_hit_ratio{b}":    0,
+	} {
+		if got[key] != want {
+			t.Errorf("got %s %v; want %v", key, got[key], want)
+		}
+	}
+}
@@ -9,30 +9,101 @@ package hashque
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.adoublef.dev/runtime/debug"
+	"golang.org/x/sync/semaphore"
 )
 
+// ErrClosed is returned when work is given to a [Group] that has been stopped.
+var ErrClosed = errors.New("hashque: group is closed")
+
+// PanicError is the error reported when a function run by a [Group] panics.
+type PanicError struct {
+	Value any    // passed to panic
+	Stack []byte // of the goroutine that panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("hashque: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// stack returns the formatted stack trace of the calling goroutine.
+func stack() []byte {
+	buf := make([]byte, 1024)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// protect calls f, converting a panic into a [PanicError].
+func protect[T any](f func() (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: stack()}
+		}
+	}()
+	return f()
+}
+
 // ValueFunc executes a function that returns a single value.
+// It panics with [ErrClosed] if the group has been stopped, and with a
+// [*PanicError] in the caller's goroutine if f panics. See [ValueFuncContext]
+// for a version that returns these as errors.
 func ValueFunc[K comparable, T any](g *Group[K], key K, f func() T) T {
-	c := make(chan T)
-	g.Do(key, func() {
+	c := make(chan Result[T])
+	err := g.Do(key, func() {
 		defer close(c)
-		c <- f()
+		c <- valueResult(f)
 	})
-	return <-c
+	if err != nil {
+		panic(err)
+	}
+	return mustValue(<-c)
 }
 
 // TryValueFunc attempts to execute a function that returns a single value, without blocking if the
-// channel is full. It returns the value and a boolean indicating success.
+// channel is full. It returns the value and a boolean indicating success. If f panics, TryValueFunc
+// panics with a [*PanicError] in the caller's goroutine.
 func TryValueFunc[K comparable, V any](g *Group[K], key K, f func() V) (V, bool) {
-	c := make(chan V, 1)
+	c := make(chan Result[V], 1)
 	ok := g.TryDo(key, func() {
 		defer close(c)
-		c <- f()
+		c <- valueResult(f)
 	})
-	return <-c, ok
+	if !ok {
+		return *new(V), false
+	}
+	return mustValue(<-c), ok
+}
+
+func valueResult[T any](f func() T) Result[T] {
+	var r Result[T]
+	r.Val, r.Err = protect(func() (T, error) { return f(), nil })
+	return r
+}
+
+// mustValue returns the value of r, panicking again if f panicked.
+func mustValue[T any](r Result[T]) T {
+	if r.Err != nil {
+		panic(r.Err)
+	}
+	return r.Val
 }
 
 // Result encapsulates the return value and error from a function call.
@@ -42,145 +113,399 @@ type Result[V any] struct {
 }
 
 // ResultFunc executes a function that returns a value and an error.
+// If f panics, the error is a [*PanicError].
 func ResultFunc[K comparable, T any](g *Group[K], key K, f func() (T, error)) (T, error) {
 	c := make(chan Result[T])
-	g.Do(key, func() {
+	err := g.Do(key, func() {
 		defer close(c)
 		var r Result[T]
-		r.Val, r.Err = f()
+		r.Val, r.Err = protect(f)
 		c <- r
 	})
+	if err != nil {
+		return *new(T), err
+	}
 	r := <-c
 	return r.Val, r.Err
 }
 
 // ResultChan executes a function that returns a value and an error, and returns a channel that will
-// receive the result. This allows for non-blocking usage patterns.
+// receive the result. This allows for non-blocking usage patterns. If f panics, the error is a
+// [*PanicError]. See [ResultChanContext] for a version that can be cancelled.
 func ResultChan[K comparable, V any](g *Group[K], key K, f func() (V, error)) <-chan Result[V] {
-	// NOTE: should maybe add a way to cancel this?
 	res := make(chan Result[V], 1)
-	g.Do(key, func() {
+	err := g.Do(key, func() {
 		defer close(res)
 		var r Result[V]
-		r.Val, r.Err = f()
+		r.Val, r.Err = protect(f)
 		res <- r
 	})
+	if err != nil {
+		res <- Result[V]{Err: err}
+		close(res)
+	}
+	return res
+}
+
+// ValueFuncContext is like [ValueFunc] but gives up once ctx is done, returning ctx.Err().
+// A panic in f is returned as a [*PanicError]. See [ResultFuncContext].
+func ValueFuncContext[K comparable, T any](ctx context.Context, g *Group[K], key K, f func(context.Context) T) (T, error) {
+	return ResultFuncContext(ctx, g, key, func(ctx context.Context) (T, error) { return f(ctx), nil })
+}
+
+// ResultFuncContext is like [ResultFunc] but gives up once ctx is done, returning ctx.Err().
+// If f has not started by then it is skipped when its turn comes, without waiting for a worker
+// slot, and is no longer counted by [Group.Queued], though it keeps its place in the queue of
+// key until then. Otherwise f is left to observe the cancellation of the ctx it was passed.
+func ResultFuncContext[K comparable, T any](ctx context.Context, g *Group[K], key K, f func(context.Context) (T, error)) (T, error) {
+	c, cancel, err := doResult(ctx, g, key, f)
+	if err != nil {
+		return *new(T), err
+	}
+	select {
+	case r := <-c:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		cancel()
+		return *new(T), ctx.Err()
+	}
+}
+
+// ResultChanContext is like [ResultChan] but the channel receives ctx.Err() once ctx is done.
+// See [ResultFuncContext].
+func ResultChanContext[K comparable, V any](ctx context.Context, g *Group[K], key K, f func(context.Context) (V, error)) <-chan Result[V] {
+	res := make(chan Result[V], 1)
+	c, cancel, err := doResult(ctx, g, key, f)
+	if err != nil {
+		res <- Result[V]{Err: err}
+		close(res)
+		return res
+	}
+	go func() {
+		defer close(res)
+		select {
+		case r := <-c:
+			res <- r
+		case <-ctx.Done():
+			cancel()
+			res <- Result[V]{Err: ctx.Err()}
+		}
+	}()
 	return res
 }
 
+// doResult queues f, returning a channel receiving its result and a
+// function that stops f from running if it has not started yet.
+func doResult[K comparable, V any](ctx context.Context, g *Group[K], key K, f func(context.Context) (V, error)) (<-chan Result[V], func(), error) {
+	c := make(chan Result[V], 1)
+	t := task{ctx: ctx, state: new(atomic.Int32), f: func() {
+		var r Result[V]
+		r.Val, r.Err = protect(func() (V, error) { return f(ctx) })
+		c <- r
+	}}
+	err := g.doTask(ctx, key, Normal, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, func() { g.cancel(t) }, nil
+}
+
+// States of a task that can be cancelled.
+const (
+	queued int32 = iota
+	started
+	cancelled
+)
+
+// task is a function waiting in the queue of a key. A task with a state
+// is skipped if it is cancelled, or its ctx is done, before it starts.
+type task struct {
+	f     func()
+	ctx   context.Context
+	state *atomic.Int32
+}
+
+// cancel stops t from starting, no longer counting it as queued.
+func (g *Group[K]) cancel(t task) {
+	if t.state.CompareAndSwap(queued, cancelled) {
+		g.nqueued.Add(-1)
+	}
+}
+
 type call struct {
-	funcs chan func()
+	lanes [numPriorities]chan task // by [Priority.lane]
 	count int64
+	skips [numPriorities]int // of functions run ahead of each lane, see [call.pick]
 }
 
 // Group provides a mechanism to deduplicate function calls by key. When multiple goroutines
 // call functions with the same key concurrently, only one execution occurs while all callers
 // receive the result of that execution.
 type Group[K comparable] struct {
-	mu sync.Mutex
-	m  map[K]*call
+	// QueueDepth is the number of functions of each [Priority] that can be
+	// queued for a key before Do blocks and TryDo fails. Zero means 16.
+	QueueDepth int
+
+	// IdleTimeout is how long the worker of a key waits for more work once
+	// its queue is empty before exiting. Zero means it exits straight away.
+	IdleTimeout time.Duration
+
+	// MaxWorkers is the maximum number of functions, across all keys, that
+	// run at once. Work for other keys waits its turn while keeping its
+	// place in the queue of its key. Zero means no limit.
+	// It must be set before the first call to Do.
+	MaxWorkers int64
+
+	// OnPanic is called when a function given to Do, TryDo, DoContext or
+	// DoPriority panics, after which the worker of key goes on to the next
+	// function in its queue. Nil means the panic is raised again, crashing
+	// the program as it would in any other goroutine. Helpers such as
+	// [ResultFunc] and [ValueFunc] report panics to their caller instead.
+	OnPanic func(key K, err *PanicError)
+
+	mu     sync.Mutex
+	m      map[K]*call
+	wg     sync.WaitGroup // of running workers
+	stop   chan struct{}  // closed by Stop
+	closed bool
+	sem    *semaphore.Weighted
+
+	nqueued, nactive atomic.Int64
 }
 
-// Do executes the given function once for each key, blocking until the function completes.
-func (g *Group[K]) Do(key K, f func()) {
-	c := g.loadCall(key)
+// Queued returns the number of functions waiting to run.
+func (g *Group[K]) Queued() int64 { return g.nqueued.Load() }
+
+// Active returns the number of functions running.
+func (g *Group[K]) Active() int64 { return g.nactive.Load() }
+
+// Do executes the given function once for each key, blocking until the function is queued.
+// It returns [ErrClosed] if the group has been stopped.
+func (g *Group[K]) Do(key K, f func()) error {
+	c, err := g.loadCall(key)
+	if err != nil {
+		return err
+	}
 
-	c.funcs <- f
+	c.lanes[Normal.lane()] <- task{f: f}
+	return nil
 }
 
 // TryDo attempts to queue the given function for execution but doesn't block if the channel
 // is full. It returns a boolean indicating whether the function was successfully queued.
 func (g *Group[K]) TryDo(key K, f func()) bool {
-	c := g.loadCall(key)
+	c, err := g.loadCall(key)
+	if err != nil {
+		return false
+	}
 
 	select {
-	case c.funcs <- f:
+	case c.lanes[Normal.lane()] <- task{f: f}:
 		return true
 	default:
-		func() {
-			g.mu.Lock()
-			defer g.mu.Unlock()
-
-			if c.count--; c.count == 0 {
-				// we're the last waiter therefore
-				// closing the channel is ok.
-				delete(g.m, key)
-				close(c.funcs)
-			}
-		}()
+		g.release(c, key)
 		return false
 	}
 }
 
 // DoContext attempts to queue the given function for execution but doesn't block if the channel
-// is full. It returns an error if the context is cancelled or if the queue is full.
-// The key parameter determines which worker the function will be queued to.
+// is full. It returns an error if the context is cancelled, if the queue is full or if the group
+// has been stopped. The key parameter determines which worker the function will be queued to.
 func (g *Group[K]) DoContext(ctx context.Context, key K, f func()) error {
-	// this could need context?
-	c := g.loadCall(key)
+	return g.DoPriority(ctx, key, Normal, f)
+}
+
+// DoPriority is like [Group.DoContext] but queues the function with priority p, so that it runs
+// ahead of any lower priority functions queued for the same key.
+func (g *Group[K]) DoPriority(ctx context.Context, key K, p Priority, f func()) error {
+	return g.doTask(ctx, key, p, task{f: f})
+}
+
+func (g *Group[K]) doTask(ctx context.Context, key K, p Priority, t task) error {
+	c, err := g.loadCall(key)
+	if err != nil {
+		return err
+	}
 
 	select {
-	case c.funcs <- f:
+	case c.lanes[p.lane()] <- t:
 		return nil
 	case <-ctx.Done():
-		func() {
-			g.mu.Lock()
-			defer g.mu.Unlock()
-
-			if c.count--; c.count == 0 {
-				// we're the last waiter therefore
-				// closing the channel is ok.
-				delete(g.m, key)
-				close(c.funcs)
-			}
-		}()
+		g.release(c, key)
 		return ctx.Err()
 	}
 }
 
-func (g *Group[K]) loadCall(key K) *call {
+// release gives up a place in the queue of c that was never used.
+func (g *Group[K]) release(c *call, key K) {
 	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.nqueued.Add(-1)
+	if c.count--; c.count == 0 {
+		// we're the last waiter therefore
+		// closing the channel is ok.
+		delete(g.m, key)
+		for _, l := range c.lanes {
+			close(l)
+		}
+	}
+}
+
+// Stop rejects new work with [ErrClosed] and waits for the work already
+// queued for every key to finish, returning early if ctx is done.
+func (g *Group[K]) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.closed {
+		g.closed = true
+		if g.stop == nil {
+			g.stop = make(chan struct{})
+		}
+		close(g.stop)
+	}
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.wg.Wait()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Group[K]) loadCall(key K) (*call, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil, ErrClosed
+	}
 	if g.m == nil {
 		g.m = make(map[K]*call)
+		g.stop = make(chan struct{})
+		if g.MaxWorkers > 0 {
+			g.sem = semaphore.NewWeighted(g.MaxWorkers)
+		}
 	}
 	c, ok := g.m[key]
-	if ok {
-		c.count++
-		g.mu.Unlock()
-	} else {
-		c = &call{
-			funcs: make(chan func(), 16),
+	if !ok {
+		depth := g.QueueDepth
+		if depth == 0 {
+			depth = 16
+		}
+		c = new(call)
+		for i := range c.lanes {
+			c.lanes[i] = make(chan task, depth)
 		}
-		c.count++
 		g.m[key] = c
-		g.mu.Unlock()
-
-		go g.doCall(c, key)
+		g.wg.Go(func() { g.doCall(c, key, g.stop) })
 	}
-	return c
+	c.count++
+	g.nqueued.Add(1)
+	return c, nil
 }
 
-func (g *Group[K]) doCall(c *call, key K) {
+func (g *Group[K]) doCall(c *call, key K, stop <-chan struct{}) {
 	defer debug.Printf("sync/hashqueue: closing call for key %v", key)
-	normalReturn := false
 
-	for f := range c.funcs {
-		f()
+	var idle <-chan time.Time
+	for {
+		t, ok, closed := c.pick()
+		if closed {
+			return
+		}
+		if !ok {
+			select {
+			case t, ok = <-c.lanes[0]:
+			case t, ok = <-c.lanes[1]:
+			case t, ok = <-c.lanes[2]:
+			case <-idle:
+				if g.retire(c, key) {
+					return
+				}
+				idle = nil
+				continue
+			case <-stop:
+				if g.retire(c, key) {
+					return
+				}
+				// finish the queued work then exit
+				stop, idle = nil, nil
+				continue
+			}
+			if !ok {
+				return
+			}
+		}
+		g.run(key, t)
 
-		func() {
-			g.mu.Lock()
-			defer g.mu.Unlock()
+		g.mu.Lock()
+		c.count--
+		empty := c.count == 0
+		g.mu.Unlock()
+		if empty && (g.IdleTimeout <= 0 || g.isClosed()) && g.retire(c, key) {
+			return
+		}
+		if empty {
+			idle = time.After(g.IdleTimeout)
+		}
+	}
+}
 
-			if c.count--; c.count == 0 {
-				delete(g.m, key)
-				// closing the channel not needed
-				// but look to see if I can.
-				normalReturn = true
-			}
-		}()
+// retire removes the call of key if no more work has been queued for it.
+func (g *Group[K]) retire(c *call, key K) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c.count != 0 {
+		return false
+	}
+	delete(g.m, key)
+	return true
+}
+
+func (g *Group[K]) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
 
-		if normalReturn {
+// run calls the function of t once a worker slot is free, passing a panic
+// to OnPanic so that the worker of key can go on to the next function in
+// its queue. A cancelled task is skipped without waiting for a slot.
+func (g *Group[K]) run(key K, t task) {
+	if t.state != nil && t.state.Load() == cancelled {
+		return
+	}
+	if g.sem != nil {
+		ctx := t.ctx
+		if ctx == nil {
+			ctx = context.Background() // cannot fail
+		}
+		if err := g.sem.Acquire(ctx, 1); err != nil {
+			g.cancel(t)
 			return
 		}
+		defer g.sem.Release(1)
 	}
+	if t.state != nil && !t.state.CompareAndSwap(queued, started) {
+		return // cancelled while waiting for a slot
+	}
+	g.nqueued.Add(-1)
+	g.nactive.Add(1)
+	defer g.nactive.Add(-1)
+	defer func() {
+		if r := recover(); r != nil {
+			err := &PanicError{Value: r, Stack: stack()}
+			if g.OnPanic == nil {
+				panic(err)
+			}
+			g.OnPanic(key, err)
+		}
+	}()
+	t.f()
 }
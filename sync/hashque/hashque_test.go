@@ -6,10 +6,16 @@
 package hashque_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"testing/synctest"
+	"time"
 
 	. "go.adoublef.dev/sync/hashque"
 	"go.adoublef.dev/testing/is"
@@ -92,4 +98,227 @@ func TestGroup(t *testing.T) {
 		is.True(t, nf > 0)
 		is.Equal(t, ns+nf, int64(Delta))
 	})
+
+	t.Run("QueueDepth", func(t *testing.T) {
+		g := Group[string]{QueueDepth: 1}
+		block, running := make(chan struct{}), make(chan struct{})
+		is.OK(t, g.Do("1", func() { close(running); <-block }))
+		<-running
+		is.True(t, g.TryDo("1", func() {}))  // fills the queue
+		is.True(t, !g.TryDo("1", func() {})) // queue is full
+		close(block)
+		is.OK(t, g.Stop(t.Context()))
+	})
+
+	t.Run("IdleTimeout", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[string]{IdleTimeout: time.Hour}
+			is.Equal(t, ValueFunc(&g, "1", func() int { return 1 }), 1)
+			time.Sleep(time.Minute) // worker is idle
+			is.Equal(t, ValueFunc(&g, "1", func() int { return 2 }), 2)
+
+			// stopping does not wait for idle workers to time out
+			start := time.Now()
+			is.OK(t, g.Stop(t.Context()))
+			is.Equal(t, time.Since(start), time.Duration(0))
+		})
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var g Group[string]
+			var count atomic.Int64
+			for i := range 10 {
+				is.OK(t, g.Do(strconv.Itoa(i%2), func() {
+					time.Sleep(time.Second)
+					count.Add(1)
+				}))
+			}
+			is.OK(t, g.Stop(t.Context()))
+			is.Equal(t, count.Load(), int64(10)) // drained
+
+			is.True(t, errors.Is(g.Do("1", func() {}), ErrClosed))
+			is.True(t, !g.TryDo("1", func() {}))
+			_, err := ResultFunc(&g, "1", func() (int, error) { return 1, nil })
+			is.True(t, errors.Is(err, ErrClosed))
+			func() {
+				defer func() { is.Equal(t, recover(), any(ErrClosed)) }()
+				ValueFunc(&g, "1", func() int { return 1 })
+			}()
+		})
+	})
+
+	t.Run("StopContext", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var g Group[string]
+			is.OK(t, g.Do("1", func() { time.Sleep(time.Minute) }))
+			ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+			defer cancel()
+			is.True(t, errors.Is(g.Stop(ctx), context.DeadlineExceeded))
+			is.OK(t, g.Stop(t.Context()))
+		})
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		var panics atomic.Int64
+		g := Group[string]{OnPanic: func(key string, err *PanicError) {
+			is.Equal(t, key, "1")
+			is.Equal(t, err.Value, any("boom"))
+			panics.Add(1)
+		}}
+		errBoom := errors.New("boom")
+
+		_, err := ResultFunc(&g, "1", func() (int, error) { panic(errBoom) })
+		var pe *PanicError
+		is.True(t, errors.As(err, &pe))
+		is.True(t, errors.Is(err, errBoom))
+		is.True(t, bytes.Contains(pe.Stack, []byte("panic")))
+
+		func() {
+			defer func() {
+				_, ok := recover().(*PanicError)
+				is.True(t, ok)
+			}()
+			ValueFunc(&g, "1", func() int { panic("boom") })
+		}()
+
+		is.OK(t, g.Do("1", func() { panic("boom") }))
+		// the queue is still usable
+		v, err := ResultFunc(&g, "1", func() (int, error) { return 1, nil })
+		is.OK(t, err)
+		is.Equal(t, v, 1)
+		is.Equal(t, panics.Load(), int64(1)) // only the one given to Do
+		is.OK(t, g.Stop(t.Context()))
+	})
+
+	t.Run("MaxWorkers", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[string]{MaxWorkers: 2}
+			var running, peak atomic.Int64
+			for i := range 10 {
+				is.OK(t, g.Do(strconv.Itoa(i), func() {
+					n := running.Add(1)
+					for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+					}
+					time.Sleep(time.Second)
+					running.Add(-1)
+				}))
+			}
+			synctest.Wait()
+			is.Equal(t, g.Active(), int64(2))
+			is.Equal(t, g.Queued(), int64(8))
+
+			is.OK(t, g.Stop(t.Context()))
+			is.Equal(t, peak.Load(), int64(2))
+			is.Equal(t, g.Active(), int64(0))
+			is.Equal(t, g.Queued(), int64(0))
+		})
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var g Group[string]
+			v, err := ValueFuncContext(t.Context(), &g, "1", func(context.Context) int { return 1 })
+			is.OK(t, err)
+			is.Equal(t, v, 1)
+			r := <-ResultChanContext(t.Context(), &g, "1", func(context.Context) (int, error) { return 2, nil })
+			is.OK(t, r.Err)
+			is.Equal(t, r.Val, 2)
+			_, err = ValueFuncContext(t.Context(), &g, "1", func(context.Context) int { panic("boom") })
+			var pe *PanicError
+			is.True(t, errors.As(err, &pe))
+
+			// cancelled while queued
+			is.OK(t, g.Do("1", func() { time.Sleep(time.Minute) }))
+			var ran atomic.Bool
+			ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+			defer cancel()
+			start := time.Now()
+			_, err = ResultFuncContext(ctx, &g, "1", func(context.Context) (int, error) {
+				ran.Store(true)
+				return 0, nil
+			})
+			is.True(t, errors.Is(err, context.DeadlineExceeded))
+			is.Equal(t, time.Since(start), time.Second)
+
+			// cancelled while running
+			start = time.Now()
+			ctx, cancel = context.WithTimeout(t.Context(), 2*time.Minute)
+			defer cancel()
+			r = <-ResultChanContext(ctx, &g, "1", func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				time.Sleep(time.Hour) // ignores cancellation
+				return 0, nil
+			})
+			is.True(t, errors.Is(r.Err, context.DeadlineExceeded))
+			is.Equal(t, time.Since(start), 2*time.Minute)
+
+			is.OK(t, g.Stop(t.Context()))
+			is.True(t, !ran.Load())
+		})
+	})
+
+	t.Run("ContextMaxWorkers", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			g := Group[string]{MaxWorkers: 1}
+			is.OK(t, g.Do("a", func() { time.Sleep(time.Hour) })) // holds the only slot
+			synctest.Wait()
+
+			var ran atomic.Bool
+			for range 2 {
+				ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+				_, err := ResultFuncContext(ctx, &g, "b", func(context.Context) (int, error) {
+					ran.Store(true)
+					return 0, nil
+				})
+				cancel()
+				is.True(t, errors.Is(err, context.DeadlineExceeded))
+			}
+			// neither waits for the slot, nor is counted as queued
+			synctest.Wait()
+			is.Equal(t, g.Queued(), int64(0))
+			is.Equal(t, g.Active(), int64(1))
+
+			is.OK(t, g.Stop(t.Context()))
+			is.True(t, !ran.Load())
+		})
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		// order runs a blocking function for the key then queues each of pp,
+		// returning the order in which they ran once unblocked
+		order := func(t *testing.T, pp ...Priority) []Priority {
+			g := Group[string]{QueueDepth: len(pp)}
+			block, running := make(chan struct{}), make(chan struct{})
+			is.OK(t, g.Do("1", func() { close(running); <-block }))
+			<-running
+			var got []Priority
+			for _, p := range pp {
+				is.OK(t, g.DoPriority(t.Context(), "1", p, func() { got = append(got, p) }))
+			}
+			close(block)
+			is.OK(t, g.Stop(t.Context()))
+			return got
+		}
+
+		got := order(t, Low, Normal, High, Low, High)
+		is.True(t, slices.Equal(got, []Priority{High, High, Normal, Low, Low}))
+
+		// low priority work is not starved
+		pp := []Priority{Low}
+		for range 20 {
+			pp = append(pp, High)
+		}
+		got = order(t, pp...)
+		is.Equal(t, slices.Index(got, Low), 8)
+
+		// nor is normal priority work while high and low are both busy
+		pp = []Priority{Normal}
+		for range 40 {
+			pp = append(pp, High, Low)
+		}
+		got = order(t, pp...)
+		is.Equal(t, slices.Index(got, Normal), 8)
+		is.True(t, slices.Equal(got[:10], []Priority{High, High, High, High, High, High, High, High, Normal, Low}))
+	})
 }
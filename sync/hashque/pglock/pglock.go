@@ -0,0 +1,151 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pglock serializes function calls by key across processes using
+// Postgres advisory locks. It mirrors the API of [go.adoublef.dev/sync/hashque]
+// for work that must run one at a time per key over every replica of a service.
+package pglock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"go.adoublef.dev/sync/hashque"
+)
+
+// Beginner begins transactions. It is implemented by
+// [github.com/jackc/pgx/v5/pgxpool.Pool].
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Group serializes function calls by key. Calls for the same key run one at
+// a time across every Group sharing the database, each holding the
+// transaction-level advisory lock of the key while it runs.
+//
+// Each call holds a connection for its transaction while it waits for the
+// lock and runs. Calls for the same key first queue within the process, so
+// a Group holds at most one connection per key, but a function that needs
+// another connection from the same pool should leave room for it.
+type Group[K comparable] struct {
+	// Hash maps a key to the advisory lock taken for it. It must give the
+	// same result in every process. Nil means a 64-bit FNV-1a hash of the
+	// key formatted with the %v verb.
+	Hash func(key K) int64
+
+	db    Beginner
+	local hashque.Group[K] // of calls waiting in this process
+}
+
+// New creates a new [Group] taking its locks through db.
+func New[K comparable](db Beginner) *Group[K] {
+	assert(db != nil, "db cannot be nil")
+
+	return &Group[K]{db: db}
+}
+
+// Do executes the given function once it holds the lock for key.
+// It returns an error if the lock could not be taken or released.
+func (g *Group[K]) Do(key K, f func()) error {
+	return g.DoContext(context.Background(), key, f)
+}
+
+// DoContext is like [Group.Do] but gives up waiting for the lock once ctx is done.
+func (g *Group[K]) DoContext(ctx context.Context, key K, f func()) error {
+	return g.do(ctx, key, func(context.Context) { f() })
+}
+
+// do queues f behind the other calls for key in this process, so that only
+// one of them at a time holds a connection, then runs it holding the lock.
+func (g *Group[K]) do(ctx context.Context, key K, f func(context.Context)) (err error) {
+	const (
+		queued = iota
+		started
+		cancelled
+	)
+	var state atomic.Int32
+	var p any // recovered from f, to panic again in the caller's goroutine
+	done := make(chan struct{})
+	qerr := g.local.DoContext(ctx, key, func() {
+		defer close(done)
+		if !state.CompareAndSwap(queued, started) {
+			return
+		}
+		defer func() { p = recover() }()
+		err = g.lock(ctx, key, f)
+	})
+	if qerr != nil {
+		return qerr
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if state.CompareAndSwap(queued, cancelled) {
+			return ctx.Err()
+		}
+		<-done
+	}
+	if p != nil {
+		panic(p)
+	}
+	return err
+}
+
+func (g *Group[K]) lock(ctx context.Context, key K, f func(context.Context)) (err error) {
+	tx, err := g.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	// ending the transaction releases the lock, even if f panics
+	defer func() {
+		if rerr := tx.Rollback(context.WithoutCancel(ctx)); rerr != nil && err == nil {
+			err = fmt.Errorf("cannot release lock: %w", rerr)
+		}
+	}()
+	_, err = tx.Exec(ctx, "select pg_advisory_xact_lock($1)", g.hash(key))
+	if err != nil {
+		return fmt.Errorf("cannot acquire lock: %w", err)
+	}
+	f(ctx)
+	return nil
+}
+
+func (g *Group[K]) hash(key K) int64 {
+	if g.Hash != nil {
+		return g.Hash(key)
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%v", key)
+	return int64(h.Sum64())
+}
+
+// ValueFunc executes a function that returns a single value.
+func ValueFunc[K comparable, T any](g *Group[K], key K, f func() T) (T, error) {
+	return ResultFunc(g, key, func() (T, error) { return f(), nil })
+}
+
+// ResultFunc executes a function that returns a value and an error.
+func ResultFunc[K comparable, T any](g *Group[K], key K, f func() (T, error)) (T, error) {
+	return ResultFuncContext(context.Background(), g, key, func(context.Context) (T, error) { return f() })
+}
+
+// ResultFuncContext is like [ResultFunc] but gives up waiting for the lock
+// once ctx is done. The function is passed ctx.
+func ResultFuncContext[K comparable, T any](ctx context.Context, g *Group[K], key K, f func(context.Context) (T, error)) (v T, err error) {
+	lerr := g.do(ctx, key, func(ctx context.Context) { v, err = f(ctx) })
+	if lerr != nil {
+		return v, lerr
+	}
+	return v, err
+}
+
+func assert(exp bool, format string) {
+	if !exp {
+		panic(format)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pglock_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/testcontainers/testcontainers-go"
+	"go.adoublef.dev/runtime/container/postgres"
+	. "go.adoublef.dev/sync/hashque/pglock"
+	"go.adoublef.dev/testing/is"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("Fake", func(t *testing.T) {
+		testGroup(t, new(fakeDB))
+
+		t.Run("Connections", func(t *testing.T) {
+			db := new(fakeDB)
+			g := New[string](db)
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Go(func() {
+					is.OK(t, g.Do("tenant", func() { time.Sleep(time.Millisecond) }))
+				})
+			}
+			wg.Wait()
+			is.Equal(t, db.peak.Load(), int64(1)) // waiters queue in process
+		})
+	})
+
+	t.Run("Postgres", func(t *testing.T) {
+		testcontainers.SkipIfProviderIsNotHealthy(t)
+
+		c, err := postgres.Run(t.Context(), "")
+		is.OK(t, err)
+		t.Cleanup(func() { _ = c.Terminate(context.Background()) })
+		pool, err := c.ConnectionPool(t.Context())
+		is.OK(t, err)
+		t.Cleanup(pool.Close)
+		testGroup(t, pool)
+	})
+}
+
+func testGroup(t *testing.T, db Beginner) {
+	t.Run("Do", func(t *testing.T) {
+		// groups sharing a database stand in for replicas
+		a, b := New[string](db), New[string](db)
+
+		var running, peak atomic.Int64
+		var wg sync.WaitGroup
+		for i := range 10 {
+			g := a
+			if i%2 == 0 {
+				g = b
+			}
+			wg.Go(func() {
+				is.OK(t, g.Do("tenant", func() {
+					n := running.Add(1)
+					for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+					}
+					time.Sleep(5 * time.Millisecond)
+					running.Add(-1)
+				}))
+			})
+		}
+		wg.Wait()
+		is.Equal(t, peak.Load(), int64(1))
+	})
+
+	t.Run("Keyed", func(t *testing.T) {
+		g := New[int](db)
+		held, release := make(chan struct{}), make(chan struct{})
+		go func() {
+			_ = g.Do(1, func() { close(held); <-release })
+		}()
+		<-held
+		v, err := ResultFunc(g, 2, func() (string, error) { return strconv.Itoa(2), nil })
+		close(release)
+		is.OK(t, err)
+		is.Equal(t, v, "2")
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		g := New[string](db)
+		held, release := make(chan struct{}), make(chan struct{})
+		go func() {
+			_ = g.Do("tenant", func() { close(held); <-release })
+		}()
+		<-held
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		_, err := ResultFuncContext(ctx, g, "tenant", func(context.Context) (int, error) {
+			t.Error("ran without the lock")
+			return 0, nil
+		})
+		is.True(t, err != nil)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		g := New[string](db)
+		errTest := errors.New("test")
+		_, err := ResultFunc(g, "tenant", func() (int, error) { return 0, errTest })
+		is.True(t, errors.Is(err, errTest))
+		v, err := ValueFunc(g, "tenant", func() int { return 1 }) // lock was released
+		is.OK(t, err)
+		is.Equal(t, v, 1)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		g := New[string](db)
+		func() {
+			defer func() { is.Equal(t, recover(), any("boom")) }()
+			_ = g.Do("tenant", func() { panic("boom") })
+		}()
+		v, err := ValueFunc(g, "tenant", func() int { return 1 }) // lock was released
+		is.OK(t, err)
+		is.Equal(t, v, 1)
+	})
+}
+
+// fakeDB stands in for Postgres, implementing only the advisory locks.
+type fakeDB struct {
+	mu    sync.Mutex
+	locks map[int64]chan struct{}
+
+	open, peak atomic.Int64 // transactions
+}
+
+func (db *fakeDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	n := db.open.Add(1)
+	for p := db.peak.Load(); n > p && !db.peak.CompareAndSwap(p, n); p = db.peak.Load() {
+	}
+	return &fakeTx{db: db}, nil
+}
+
+func (db *fakeDB) lock(ctx context.Context, id int64) error {
+	for {
+		db.mu.Lock()
+		if db.locks == nil {
+			db.locks = make(map[int64]chan struct{})
+		}
+		held, ok := db.locks[id]
+		if !ok {
+			db.locks[id] = make(chan struct{})
+			db.mu.Unlock()
+			return nil
+		}
+		db.mu.Unlock()
+		select {
+		case <-held:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (db *fakeDB) unlock(id int64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	close(db.locks[id])
+	delete(db.locks, id)
+}
+
+type fakeTx struct {
+	pgx.Tx // panics on anything else
+	db     *fakeDB
+	held   []int64
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if sql != "select pg_advisory_xact_lock($1)" {
+		return pgconn.CommandTag{}, errors.New("unsupported statement")
+	}
+	id := args[0].(int64)
+	if err := tx.db.lock(ctx, id); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tx.held = append(tx.held, id)
+	return pgconn.NewCommandTag("SELECT 1"), nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error { return tx.Rollback(ctx) }
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.db.open.Add(-1)
+	for _, id := range tx.held {
+		tx.db.unlock(id)
+	}
+	tx.held = nil
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Kristopher Rahim Afful-Brown. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hashque
+
+// Priority orders the functions queued for the same key.
+// The zero value is [Normal].
+type Priority int
+
+const (
+	Normal Priority = iota
+	High
+	Low
+
+	numPriorities = 3
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	case Low:
+		return "low"
+	}
+	return "unknown"
+}
+
+// lane returns the index of the queue of p, highest priority first.
+func (p Priority) lane() int {
+	switch p {
+	case High:
+		return 0
+	case Low:
+		return 2
+	}
+	return 1
+}
+
+// maxSkips is the number of functions that may run ahead of a lane with
+// queued work before that lane is served instead.
+const maxSkips = 8
+
+// pick takes the next function queued without blocking, preferring higher
+// lanes. To stop a busy key starving its lower lanes, a lane is served once
+// maxSkips functions have jumped ahead of it, the highest such lane first.
+// It reports closed once the lanes have been closed.
+func (c *call) pick() (t task, ok, closed bool) {
+	for i, n := range c.skips {
+		if n < maxSkips {
+			continue
+		}
+		if t, ok, closed = c.take(i); ok || closed {
+			return t, ok, closed
+		}
+	}
+	for i := range c.lanes {
+		if t, ok, closed = c.take(i); ok || closed {
+			return t, ok, closed
+		}
+	}
+	return task{}, false, false
+}
+
+// take takes the next function of lane i without blocking, counting a skip
+// for every lower lane that has queued functions.
+func (c *call) take(i int) (t task, ok, closed bool) {
+	select {
+	case t, ok = <-c.lanes[i]:
+		if !ok {
+			return task{}, false, true
+		}
+		c.skips[i] = 0
+		for j := i + 1; j < len(c.lanes); j++ {
+			if len(c.lanes[j]) > 0 {
+				c.skips[j]++
+			} else {
+				c.skips[j] = 0
+			}
+		}
+		return t, true, false
+	default:
+		return task{}, false, false
+	}
+}